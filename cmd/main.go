@@ -2,6 +2,7 @@ package main
 
 import (
 	"GoSyntaxDoc/infrastructure"
+	"GoSyntaxDoc/infrastructure/auth"
 	"GoSyntaxDoc/infrastructure/redis"
 	"GoSyntaxDoc/presentation/middleware"
 	"GoSyntaxDoc/presentation/websocket"
@@ -20,7 +21,12 @@ func main() {
 	// migrations.InitDB()
 
 	// ✅ Initialize Kafka Producer (for event-driven communication)
-	producer := infrastructure.NewKafkaProducer([]string{"kafka:9092"})
+	producerConfig, err := infrastructure.KafkaProducerConfigFromEnv([]string{"kafka:9092"})
+	if err != nil {
+		fmt.Println("❌ Invalid Kafka producer configuration:", err)
+		os.Exit(1)
+	}
+	producer := infrastructure.NewKafkaProducer(producerConfig)
 	redisService := redis.NewRedisService()
 	// ✅ Initialize User Repository & Service
 	// userRepo := repositories.NewUserRepository(&database.Database)
@@ -30,7 +36,7 @@ func main() {
 	// kafkaConsumer, err := consumers.NewKafkaConsumer(
 	// 	[]string{"kafka:9092"},                              // Kafka brokers
 	// 	"user-service-group",                                // Kafka Consumer Group ID
-	// 	[]string{"user.created", "user.fetch", "user.read"}, // Kafka topics
+	// 	topics.ConsumedBy(topics.ServiceUsers),              // Kafka topics
 	// 	userService,
 	// 	redisService,
 	// )
@@ -42,15 +48,20 @@ func main() {
 	// go kafkaConsumer.ConsumeMessages()
 
 	// ✅ Set Up WebSocket Manager
-	wsManager := websocket.NewWebSocketManager(producer, redisService)
+	wsManager := websocket.NewWebSocketManager(producer, redisService, websocket.ConfigFromEnv())
 
 	// ✅ Initialize Fiber (only for WebSockets)
 	app := fiber.New()
 	app.Use(middleware.FiberLogger())
 	app.Use(middleware.RecoveryMiddleware())
 
-	// ✅ Register WebSocket Routes
-	websocket.RegisterWebsocketRoutes(app, wsManager)
+	// ✅ Register WebSocket Routes (handshake requires a JWT signed with a configured key)
+	verifier, err := auth.VerifierFromEnv()
+	if err != nil {
+		fmt.Println("❌ Invalid JWT configuration:", err)
+		os.Exit(1)
+	}
+	websocket.RegisterWebsocketRoutes(app, wsManager, verifier)
 
 	// ✅ Debug Route
 	app.Get("/fatal", func(c *fiber.Ctx) error {
@@ -72,4 +83,11 @@ func main() {
 	<-quit // Wait for shutdown signal
 
 	fmt.Println("🛑 Shutting down microservice...")
+	if err := app.Shutdown(); err != nil {
+		fmt.Println("❌ Error stopping WebSocket server:", err)
+	}
+	// ✅ Flush batched messages before exiting
+	if err := producer.Close(); err != nil {
+		fmt.Println("❌ Error closing Kafka producer:", err)
+	}
 }
@@ -1 +1,33 @@
 package config
+
+import (
+	"os"
+	"strconv"
+	"time"
+)
+
+// ✅ GetEnv returns the environment variable or the fallback when it is unset
+func GetEnv(key string, fallback string) string {
+	if value, ok := os.LookupEnv(key); ok && value != "" {
+		return value
+	}
+	return fallback
+}
+
+// ✅ GetEnvInt parses an integer environment variable, falling back on unset or invalid values
+func GetEnvInt(key string, fallback int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil {
+		return fallback
+	}
+	return value
+}
+
+// ✅ GetEnvDuration parses a duration such as "30s", falling back on unset or invalid values
+func GetEnvDuration(key string, fallback time.Duration) time.Duration {
+	value, err := time.ParseDuration(os.Getenv(key))
+	if err != nil {
+		return fallback
+	}
+	return value
+}
@@ -1,13 +1,17 @@
 package main
 
 import (
+	"GoSyntaxDoc/domain/topics"
+	"GoSyntaxDoc/infrastructure"
 	"GoSyntaxDoc/infrastructure/consumers"
 	"GoSyntaxDoc/infrastructure/database"
 	"GoSyntaxDoc/infrastructure/database/migrations"
+	"GoSyntaxDoc/infrastructure/outbox"
 	"GoSyntaxDoc/infrastructure/redis"
 	"GoSyntaxDoc/infrastructure/repositories"
 	"GoSyntaxDoc/presentation/middleware"
 	"GoSyntaxDoc/services/user"
+	"context"
 	"fmt"
 	"os"
 	"os/signal"
@@ -34,17 +38,37 @@ func main() {
 	userRepo := repositories.NewUserRepository(&database.Database)
 	userService := user.NewUserService(userRepo)
 
+	// ✅ Route Kafka topics to handlers
+	metrics := consumers.NewMetrics()
+	router := consumers.NewRouter()
+	router.Use(
+		consumers.Logging(),
+		consumers.WithMetrics(metrics),
+		consumers.Recovery(),
+		// ✅ No Dedupe: user.created has the processed_events ledger, which
+		// re-publishes the earlier result, and reads are safe to answer twice
+	)
+	replies := consumers.NewReplies(redisService)
+	consumers.NewUserHandlers(userService, replies).Register(router)
+
+	// ✅ Initialize Kafka Producer (outbox relay and dead-letter topics)
+	producerConfig, err := infrastructure.KafkaProducerConfigFromEnv([]string{"kafka:9092"})
+	if err != nil {
+		middleware.Log.Error("❌ Invalid Kafka producer configuration: ", err)
+		os.Exit(1)
+	}
+	producer := infrastructure.NewKafkaProducer(producerConfig)
+
 	// ✅ Start Kafka Consumer with Retry Mechanism
 	var kafkaConsumer *consumers.KafkaConsumer
-	var err error
 
 	for attempt := 1; attempt <= maxRetries; attempt++ {
 		kafkaConsumer, err = consumers.NewKafkaConsumer(
 			[]string{"kafka:9092"},
 			"user-service-group",
-			[]string{"user.created", "user.fetch", "user.read"},
-			userService,
-			redisService,
+			topics.ConsumedBy(topics.ServiceUsers),
+			router,
+			producer,
 		)
 		if err == nil {
 			fmt.Printf("✅ Kafka Consumer connected successfully on attempt %d\n", attempt)
@@ -61,19 +85,47 @@ func main() {
 		time.Sleep(retryInterval)
 	}
 
+	// ✅ Cancelled on Ctrl+C / SIGTERM; everything below stops on it
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
 	// ✅ Run consumer in a separate goroutine
-	go kafkaConsumer.ConsumeMessages()
+	consumerDone := make(chan struct{})
+	go func() {
+		defer close(consumerDone)
+		kafkaConsumer.ConsumeMessages(ctx)
+	}()
+
+	// ✅ Start the Outbox Relay (delivers committed events to Kafka and Redis)
+	relayDone := make(chan struct{})
+	go func() {
+		defer close(relayDone)
+		outbox.NewRelay(outbox.NewPostgresStore(database.Database.DB), producer, redisService).Run(ctx)
+	}()
 
 	fmt.Println("🚀 Kafka Consumer running... Press Ctrl+C to stop.")
 
 	// ✅ Graceful Shutdown Handling
-	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
-	<-quit // Wait for shutdown signal
+	<-ctx.Done() // Wait for shutdown signal
 
-	// ✅ Close Kafka Consumer Gracefully
+	// ✅ Close Kafka Consumer Gracefully: in-flight handlers finish and their offsets are committed
 	fmt.Println("🛑 Shutting down Kafka Consumer microservice...")
 	if err := kafkaConsumer.Close(); err != nil {
 		middleware.Log.Error("Error closing Kafka consumer: ", err)
 	}
+	<-consumerDone
+	metrics.Log()
+
+	// ✅ Replies the handlers started are still on their way to Redis
+	publishCtx, cancelPublish := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelPublish()
+	if err := replies.Wait(publishCtx); err != nil {
+		middleware.Log.Error("Gave up waiting for Redis publishes: ", err)
+	}
+
+	// ✅ Unsent outbox rows are picked up again on the next start
+	<-relayDone
+	if err := producer.Close(); err != nil {
+		middleware.Log.Error("Error closing Kafka producer: ", err)
+	}
 }
@@ -0,0 +1,18 @@
+package entities
+
+// ✅ Identity - Authenticated caller attached to a WebSocket connection
+type Identity struct {
+	Subject string   `json:"sub"`
+	Tenant  string   `json:"tenant,omitempty"`
+	Roles   []string `json:"roles,omitempty"`
+}
+
+// ✅ HasRole reports whether the identity was granted the role
+func (i *Identity) HasRole(role string) bool {
+	for _, r := range i.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
@@ -1,6 +1,7 @@
 package entities
 
 import (
+	"encoding/json"
 	"time"
 )
 
@@ -17,6 +18,24 @@ func (t JSONTime) MarshalJSON() ([]byte, error) {
 	return []byte(`"` + t.Format("2006-01-02 15:04:05") + `"`), nil
 }
 
+// ✅ Reads the format written by MarshalJSON, e.g. results stored as JSON
+func (t *JSONTime) UnmarshalJSON(data []byte) error {
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	if value == "" {
+		t.Time = time.Time{}
+		return nil
+	}
+	parsed, err := time.Parse("2006-01-02 15:04:05", value)
+	if err != nil {
+		return err
+	}
+	t.Time = parsed
+	return nil
+}
+
 // ✅ User Entity (Business Model)
 type User struct {
 	ID        int      `json:"id"`
@@ -0,0 +1,76 @@
+package events
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// EnvelopeSchemaVersion is bumped on breaking changes to Envelope; see docs/websocket_protocol.md
+const EnvelopeSchemaVersion = 1
+
+// ✅ Reason codes carried by failure envelopes
+const (
+	ReasonInvalidPayload = "invalid_payload"
+	ReasonValidation     = "validation_failed"
+	ReasonNotFound       = "not_found"
+	ReasonInternal       = "internal_error"
+)
+
+// ✅ Envelope - The wire contract for every event the gateway pushes to clients
+type Envelope struct {
+	Event         string      `json:"event"`                    // Entity, e.g. "user"
+	Type          string      `json:"type"`                     // What happened, e.g. "created" or "fetch.failed"
+	EventID       string      `json:"event_id"`                 // Unique per envelope
+	Timestamp     time.Time   `json:"timestamp"`                // UTC, RFC 3339
+	SchemaVersion int         `json:"schema_version"`           // EnvelopeSchemaVersion
+	CorrelationID string      `json:"correlation_id,omitempty"` // The request_id of the command this answers
+	Payload       interface{} `json:"payload,omitempty"`
+	Error         *ReplyError `json:"error,omitempty"` // Set on "*.failed" types
+}
+
+// ✅ ReplyError - Why a command failed
+type ReplyError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// ReplyChannel carries results of commands to the gateway, which routes them by connection ID
+const ReplyChannel = "users_actions"
+
+// ✅ RedisMessage - What the consumer publishes to Redis; the gateway strips
+// ConnectionID and Tenant and forwards only the envelope to the client
+type RedisMessage struct {
+	ConnectionID string   `json:"connection_id,omitempty"` // Empty for channel broadcasts
+	Tenant       string   `json:"tenant,omitempty"`        // Broadcasts reach only subscribers of this tenant
+	Envelope     Envelope `json:"envelope"`
+}
+
+func NewEnvelope(event string, eventType string, correlationID string, payload interface{}) Envelope {
+	return Envelope{
+		Event:         event,
+		Type:          eventType,
+		EventID:       uuid.NewString(),
+		Timestamp:     time.Now().UTC(),
+		SchemaVersion: EnvelopeSchemaVersion,
+		CorrelationID: correlationID,
+		Payload:       payload,
+	}
+}
+
+// ✅ NewFailureEnvelope: e.g. event "user", type "fetch" becomes "user" / "fetch.failed"
+func NewFailureEnvelope(event string, eventType string, correlationID string, reason string, message string) Envelope {
+	envelope := NewEnvelope(event, FailedType(eventType), correlationID, nil)
+	envelope.Error = &ReplyError{Code: reason, Message: message}
+	return envelope
+}
+
+// ✅ Name is the dotted event name, also used as the Redis broadcast channel
+func (e Envelope) Name() string {
+	return e.Event + "." + e.Type
+}
+
+// ✅ FailedType names the failure type for a command type, e.g. "fetch.failed"
+func FailedType(eventType string) string {
+	return eventType + ".failed"
+}
@@ -1,6 +1,17 @@
 package events
 
+import "GoSyntaxDoc/domain/entities"
+
+// ✅ EventMetadata - Routing information stamped on every command by the WebSocket gateway
+type EventMetadata struct {
+	EventID      string             `json:"event_id,omitempty"`      // Unique per command; also the event-id header
+	ConnectionID string             `json:"connection_id,omitempty"` // Gateway connection that issued the command
+	RequestID    string             `json:"request_id,omitempty"`    // Client-supplied ID echoed back in the reply
+	Identity     *entities.Identity `json:"identity,omitempty"`      // Authenticated caller from the handshake token
+}
+
 type KafkaUserCreatedEvent struct {
+	EventMetadata
 	Event string `json:"event"`
 	Type  string `json:"type"`
 	Data  struct {
@@ -10,6 +21,7 @@ type KafkaUserCreatedEvent struct {
 }
 
 type KafkaUserFetchByIdEvent struct {
+	EventMetadata
 	Event string `json:"event"`
 	Type  string `json:"type"`
 	Data  struct {
@@ -18,6 +30,7 @@ type KafkaUserFetchByIdEvent struct {
 }
 
 type KafkaUserReadAllEvent struct {
+	EventMetadata
 	Event string                 `json:"event"`
 	Type  string                 `json:"type"`
 	Data  map[string]interface{} `json:"data"` // Allows any JSON object
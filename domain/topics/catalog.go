@@ -0,0 +1,198 @@
+package topics
+
+import (
+	"GoSyntaxDoc/domain/events"
+	"fmt"
+	"time"
+)
+
+// ✅ Topic names; use these instead of string literals
+const (
+	UserCreated = "user.created"
+	UserFetch   = "user.fetch"
+	UserRead    = "user.read"
+	UserEvents  = "user.events"
+)
+
+// ✅ Services that produce or consume topics
+const (
+	ServiceGateway = "ws-gateway"   // cmd/main.go
+	ServiceUsers   = "user-service" // consumer_starter/consumer_main.go
+)
+
+// ✅ Topic - Everything the services and the admin tool need to know about a topic
+type Topic struct {
+	Name              string
+	Partitions        int
+	ReplicationFactor int
+	Retention         time.Duration // retention.ms; 0 keeps the broker default
+	CleanupPolicy     string        // cleanup.policy; empty keeps the broker default
+	Payload           interface{}   // Zero value of the message body type
+	Producer          string        // Service that writes the topic
+	Consumers         []string      // Services that read it; empty for topics read outside this repo
+
+	// ✅ Commands sent by WebSocket clients: the event/type pair and the JSON
+	// schema of its "data"; empty for topics the gateway does not produce
+	Event  string
+	Type   string
+	Schema string
+}
+
+var catalog = []Topic{
+	{
+		Name:              UserCreated,
+		Partitions:        1,
+		ReplicationFactor: 1,
+		Retention:         7 * 24 * time.Hour,
+		CleanupPolicy:     "delete",
+		Payload:           events.KafkaUserCreatedEvent{},
+		Producer:          ServiceGateway,
+		Consumers:         []string{ServiceUsers},
+		Event:             "user",
+		Type:              "created",
+		Schema: `{
+			"type": "object",
+			"required": ["first_name", "last_name"],
+			"additionalProperties": false,
+			"properties": {
+				"first_name": {"type": "string", "minLength": 1, "maxLength": 255},
+				"last_name": {"type": "string", "minLength": 1, "maxLength": 255}
+			}
+		}`,
+	},
+	{
+		Name:              UserFetch,
+		Partitions:        1,
+		ReplicationFactor: 1,
+		Retention:         7 * 24 * time.Hour,
+		CleanupPolicy:     "delete",
+		Payload:           events.KafkaUserFetchByIdEvent{},
+		Producer:          ServiceGateway,
+		Consumers:         []string{ServiceUsers},
+		Event:             "user",
+		Type:              "fetch",
+		Schema: `{
+			"type": "object",
+			"required": ["user_id"],
+			"additionalProperties": false,
+			"properties": {
+				"user_id": {"type": "integer", "minimum": 1}
+			}
+		}`,
+	},
+	{
+		Name:              UserRead,
+		Partitions:        1,
+		ReplicationFactor: 1,
+		Retention:         7 * 24 * time.Hour,
+		CleanupPolicy:     "delete",
+		Payload:           events.KafkaUserReadAllEvent{},
+		Producer:          ServiceGateway,
+		Consumers:         []string{ServiceUsers},
+		Event:             "user",
+		Type:              "read",
+		Schema:            `{"type": "object"}`,
+	},
+	{
+		Name:              UserEvents,
+		Partitions:        1,
+		ReplicationFactor: 1,
+		Retention:         30 * 24 * time.Hour,
+		CleanupPolicy:     "delete",
+		Payload:           events.Envelope{},
+		Producer:          ServiceUsers, // ✅ Through the outbox relay
+	},
+}
+
+// ✅ RetryDelays: One retry topic per delay for every consumed topic, shortest first
+var RetryDelays = []time.Duration{10 * time.Second, time.Minute, 10 * time.Minute}
+
+// ✅ Retry: e.g. Retry("user.created", time.Minute) is "user.created.retry.1m"
+func Retry(name string, delay time.Duration) string {
+	return name + ".retry." + DelayLabel(delay)
+}
+
+// ✅ DeadLetter: Where messages from name go once they fail permanently
+func DeadLetter(name string) string {
+	return name + ".dlq"
+}
+
+// ✅ DelayLabel: 10s, 1m, 2h
+func DelayLabel(delay time.Duration) string {
+	switch {
+	case delay >= time.Hour && delay%time.Hour == 0:
+		return fmt.Sprintf("%dh", delay/time.Hour)
+	case delay >= time.Minute && delay%time.Minute == 0:
+		return fmt.Sprintf("%dm", delay/time.Minute)
+	default:
+		return fmt.Sprintf("%ds", delay/time.Second)
+	}
+}
+
+// ✅ Catalog: The declared topics, in declaration order
+func Catalog() []Topic {
+	return append([]Topic(nil), catalog...)
+}
+
+// ✅ All: The declared topics followed by the retry and dead-letter topics of every consumed one
+func All() []Topic {
+	all := Catalog()
+	for _, topic := range catalog {
+		if len(topic.Consumers) == 0 {
+			continue
+		}
+		for _, delay := range RetryDelays {
+			all = append(all, derived(topic, Retry(topic.Name, delay), 24*time.Hour))
+		}
+		all = append(all, derived(topic, DeadLetter(topic.Name), 14*24*time.Hour))
+	}
+	return all
+}
+
+// derived: Retry and dead-letter topics are written and read by the consumers of the source topic
+func derived(source Topic, name string, retention time.Duration) Topic {
+	return Topic{
+		Name:              name,
+		Partitions:        source.Partitions,
+		ReplicationFactor: source.ReplicationFactor,
+		Retention:         retention,
+		CleanupPolicy:     source.CleanupPolicy,
+		Payload:           source.Payload,
+		Producer:          source.Consumers[0],
+		Consumers:         source.Consumers,
+	}
+}
+
+// ✅ Lookup: The declared or derived topic called name
+func Lookup(name string) (Topic, bool) {
+	for _, topic := range All() {
+		if topic.Name == name {
+			return topic, true
+		}
+	}
+	return Topic{}, false
+}
+
+// ✅ ConsumedBy: Names of the declared topics service reads
+func ConsumedBy(service string) []string {
+	var names []string
+	for _, topic := range catalog {
+		for _, consumer := range topic.Consumers {
+			if consumer == service {
+				names = append(names, topic.Name)
+			}
+		}
+	}
+	return names
+}
+
+// ✅ ProducedBy: The declared topics service writes
+func ProducedBy(service string) []Topic {
+	var produced []Topic
+	for _, topic := range catalog {
+		if topic.Producer == service {
+			produced = append(produced, topic)
+		}
+	}
+	return produced
+}
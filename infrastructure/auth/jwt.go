@@ -0,0 +1,219 @@
+package auth
+
+import (
+	"GoSyntaxDoc/config"
+	"GoSyntaxDoc/domain/entities"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"strings"
+	"time"
+)
+
+// DefaultKeyID is used for tokens without a "kid" header and for a bare JWT_SECRET
+const DefaultKeyID = "default"
+
+var (
+	ErrMalformedToken   = errors.New("malformed token")
+	ErrUnsupportedAlg   = errors.New("unsupported signing algorithm")
+	ErrUnknownKey       = errors.New("unknown signing key")
+	ErrInvalidSignature = errors.New("invalid token signature")
+	ErrTokenExpired     = errors.New("token expired")
+	ErrTokenNotYetValid = errors.New("token not yet valid")
+	ErrInvalidClaims    = errors.New("invalid token claims")
+)
+
+var algorithms = map[string]func() hash.Hash{
+	"HS256": sha256.New,
+	"HS384": sha512.New384,
+	"HS512": sha512.New,
+}
+
+// ✅ Claims - Registered claims we check plus the identity claims we attach to connections
+type Claims struct {
+	Subject   string   `json:"sub"`
+	Tenant    string   `json:"tenant,omitempty"`
+	Roles     []string `json:"roles,omitempty"`
+	Issuer    string   `json:"iss,omitempty"`
+	Audience  Audience `json:"aud,omitempty"`
+	ExpiresAt int64    `json:"exp,omitempty"`
+	NotBefore int64    `json:"nbf,omitempty"`
+	IssuedAt  int64    `json:"iat,omitempty"`
+}
+
+// ✅ Audience accepts both the string and the array form of "aud"
+type Audience []string
+
+func (a *Audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = Audience{single}
+		return nil
+	}
+	var many []string
+	if err := json.Unmarshal(data, &many); err != nil {
+		return err
+	}
+	*a = many
+	return nil
+}
+
+type header struct {
+	Alg string `json:"alg"`
+	Typ string `json:"typ,omitempty"`
+	Kid string `json:"kid,omitempty"`
+}
+
+// ✅ JWTVerifier - Checks HMAC-signed JWTs against locally configured keys
+type JWTVerifier struct {
+	Keys     map[string][]byte // kid → shared secret
+	Issuer   string            // Required "iss" when non-empty
+	Audience string            // Required entry in "aud" when non-empty
+	Leeway   time.Duration     // Clock skew tolerated on exp/nbf
+}
+
+func NewJWTVerifier(keys map[string][]byte) *JWTVerifier {
+	return &JWTVerifier{Keys: keys, Leeway: 30 * time.Second}
+}
+
+// ✅ VerifierFromEnv: Keys from JWT_HMAC_KEYS ("kid:secret,kid2:secret2") or JWT_SECRET
+func VerifierFromEnv() (*JWTVerifier, error) {
+	keys := make(map[string][]byte)
+	for _, pair := range strings.Split(config.GetEnv("JWT_HMAC_KEYS", ""), ",") {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		}
+		kid, secret, ok := strings.Cut(pair, ":")
+		if !ok || kid == "" || secret == "" {
+			return nil, fmt.Errorf("invalid JWT_HMAC_KEYS entry %q, expected kid:secret", pair)
+		}
+		keys[kid] = []byte(secret)
+	}
+	if secret := config.GetEnv("JWT_SECRET", ""); secret != "" {
+		keys[DefaultKeyID] = []byte(secret)
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("no JWT keys configured, set JWT_HMAC_KEYS or JWT_SECRET")
+	}
+
+	verifier := NewJWTVerifier(keys)
+	verifier.Issuer = config.GetEnv("JWT_ISSUER", "")
+	verifier.Audience = config.GetEnv("JWT_AUDIENCE", "")
+	verifier.Leeway = config.GetEnvDuration("JWT_LEEWAY", verifier.Leeway)
+	return verifier, nil
+}
+
+// ✅ Verify: Validates signature and claims, returning the identity carried by the token
+func (v *JWTVerifier) Verify(token string) (*entities.Identity, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrMalformedToken
+	}
+
+	var hdr header
+	if err := decodeSegment(parts[0], &hdr); err != nil {
+		return nil, ErrMalformedToken
+	}
+	newHash, ok := algorithms[hdr.Alg]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedAlg, hdr.Alg)
+	}
+	kid := hdr.Kid
+	if kid == "" {
+		kid = DefaultKeyID
+	}
+	key, ok := v.Keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownKey, kid)
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrMalformedToken
+	}
+	if !hmac.Equal(signature, sign(newHash, key, parts[0]+"."+parts[1])) {
+		return nil, ErrInvalidSignature
+	}
+
+	var claims Claims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, ErrMalformedToken
+	}
+	if err := v.validate(claims, time.Now()); err != nil {
+		return nil, err
+	}
+
+	return &entities.Identity{
+		Subject: claims.Subject,
+		Tenant:  claims.Tenant,
+		Roles:   claims.Roles,
+	}, nil
+}
+
+func (v *JWTVerifier) validate(claims Claims, now time.Time) error {
+	if claims.Subject == "" {
+		return fmt.Errorf("%w: missing sub", ErrInvalidClaims)
+	}
+	if claims.ExpiresAt == 0 {
+		return fmt.Errorf("%w: missing exp", ErrInvalidClaims)
+	}
+	if now.Add(-v.Leeway).Unix() >= claims.ExpiresAt {
+		return ErrTokenExpired
+	}
+	if claims.NotBefore != 0 && now.Add(v.Leeway).Unix() < claims.NotBefore {
+		return ErrTokenNotYetValid
+	}
+	if v.Issuer != "" && claims.Issuer != v.Issuer {
+		return fmt.Errorf("%w: unexpected iss %q", ErrInvalidClaims, claims.Issuer)
+	}
+	if v.Audience != "" && !contains(claims.Audience, v.Audience) {
+		return fmt.Errorf("%w: audience %q not granted", ErrInvalidClaims, v.Audience)
+	}
+	return nil
+}
+
+// ✅ Sign: Issues a token for the claims, used by tests and local tooling
+func Sign(alg string, kid string, key []byte, claims Claims) (string, error) {
+	newHash, ok := algorithms[alg]
+	if !ok {
+		return "", fmt.Errorf("%w: %q", ErrUnsupportedAlg, alg)
+	}
+	hdr, err := json.Marshal(header{Alg: alg, Typ: "JWT", Kid: kid})
+	if err != nil {
+		return "", err
+	}
+	body, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	signingInput := base64.RawURLEncoding.EncodeToString(hdr) + "." + base64.RawURLEncoding.EncodeToString(body)
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(sign(newHash, key, signingInput)), nil
+}
+
+func sign(newHash func() hash.Hash, key []byte, signingInput string) []byte {
+	mac := hmac.New(newHash, key)
+	mac.Write([]byte(signingInput))
+	return mac.Sum(nil)
+}
+
+func decodeSegment(segment string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,50 @@
+package consumers
+
+import (
+	"GoSyntaxDoc/domain/topics"
+	"GoSyntaxDoc/infrastructure"
+	"strconv"
+)
+
+// ✅ Headers added to dead-lettered messages, on top of the original ones
+const (
+	HeaderDLQTopic     = "dlq-original-topic"
+	HeaderDLQPartition = "dlq-original-partition"
+	HeaderDLQOffset    = "dlq-original-offset"
+	HeaderDLQError     = "dlq-error"
+	HeaderDLQAttempts  = "dlq-attempts"
+)
+
+// ✅ DeadLetterTopic: Where messages from topic go once they fail permanently
+func DeadLetterTopic(topic string) string {
+	return topics.DeadLetter(topic)
+}
+
+// ✅ DeadLetterQueue - Parks messages that could not be handled, keeping key, body and headers
+type DeadLetterQueue struct {
+	Producer infrastructure.Producer
+}
+
+func NewDeadLetterQueue(producer infrastructure.Producer) *DeadLetterQueue {
+	return &DeadLetterQueue{Producer: producer}
+}
+
+// ✅ Publish: Sends msg to its dead-letter topic and waits for the broker
+func (d *DeadLetterQueue) Publish(msg Message, attempts int, cause error) error {
+	headers := make(infrastructure.Headers, len(msg.Headers)+5)
+	for name, value := range msg.Headers {
+		headers[name] = value
+	}
+	headers[HeaderDLQTopic] = msg.Topic
+	headers[HeaderDLQPartition] = strconv.Itoa(msg.Partition)
+	headers[HeaderDLQOffset] = strconv.FormatInt(msg.Offset, 10)
+	headers[HeaderDLQError] = cause.Error()
+	headers[HeaderDLQAttempts] = strconv.Itoa(attempts)
+
+	return d.Producer.Produce(infrastructure.Message{
+		Topic:   DeadLetterTopic(msg.Topic),
+		Key:     string(msg.Key),
+		Value:   msg.Value,
+		Headers: headers,
+	})
+}
@@ -0,0 +1,99 @@
+package consumers
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// permanentError marks a failure that retrying cannot fix
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string {
+	return e.err.Error()
+}
+
+func (e *permanentError) Unwrap() error {
+	return e.err
+}
+
+// ✅ Permanent: Marks err so the message goes to the dead-letter topic without retries
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// ✅ IsPermanent: True for errors marked Permanent, undecodable bodies, unrouted
+// topics and handler panics, which would fail the same way on every attempt
+func IsPermanent(err error) bool {
+	var permanent *permanentError
+	var decodeErr *DecodeError
+	var panicErr *PanicError
+	return errors.As(err, &permanent) ||
+		errors.As(err, &decodeErr) ||
+		errors.As(err, &panicErr) ||
+		errors.Is(err, ErrNoHandler)
+}
+
+// transientError marks a failure that may succeed if tried again later
+type transientError struct {
+	err error
+}
+
+func (e *transientError) Error() string {
+	return e.err.Error()
+}
+
+func (e *transientError) Unwrap() error {
+	return e.err
+}
+
+// ✅ Transient: Marks err so the message is retried through the retry topics
+func Transient(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &transientError{err: err}
+}
+
+// ✅ transientPgCodes: Postgres errors caused by the server's state, not the statement
+var transientPgCodes = map[string]bool{
+	"40001": true, // serialization_failure
+	"40P01": true, // deadlock_detected
+	"53300": true, // too_many_connections
+	"57P01": true, // admin_shutdown
+	"57P03": true, // cannot_connect_now
+}
+
+// ✅ IsTransient: True for errors marked Transient, timeouts, network and
+// connection failures, and Postgres errors that clear up by themselves.
+// Permanent errors are never transient.
+func IsTransient(err error) bool {
+	if err == nil || IsPermanent(err) {
+		return false
+	}
+
+	var transient *transientError
+	var netErr net.Error
+	var connectErr *pgconn.ConnectError
+	var pgErr *pgconn.PgError
+	switch {
+	case errors.As(err, &transient),
+		errors.Is(err, context.DeadlineExceeded),
+		errors.As(err, &netErr),
+		errors.As(err, &connectErr),
+		pgconn.Timeout(err),
+		pgconn.SafeToRetry(err):
+		return true
+	case errors.As(err, &pgErr):
+		return transientPgCodes[pgErr.Code] || strings.HasPrefix(pgErr.Code, "08") // connection_exception class
+	}
+	return false
+}
@@ -1,40 +1,73 @@
 package consumers
 
 import (
-	"GoSyntaxDoc/domain/events"
-	"GoSyntaxDoc/infrastructure/redis"
-	"GoSyntaxDoc/services/user"
+	"GoSyntaxDoc/config"
+	catalog "GoSyntaxDoc/domain/topics"
+	"GoSyntaxDoc/infrastructure"
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
+	"hash/fnv"
+	"io"
+	"strconv"
+	"sync"
 	"time"
 
 	"github.com/segmentio/kafka-go"
 	"github.com/sirupsen/logrus"
 )
 
-const RedisChannel = "users_actions"
+// ✅ MessageReader - The part of *kafka.Reader the consumer uses
+// Fetched messages are not committed until CommitMessages is called for them.
+type MessageReader interface {
+	FetchMessage(ctx context.Context) (kafka.Message, error)
+	CommitMessages(ctx context.Context, msgs ...kafka.Message) error
+	Close() error
+}
 
 type KafkaConsumer struct {
-	Reader       *kafka.Reader
-	UserService  *user.UserService
-	RedisService *redis.RedisService
+	Reader       MessageReader   // Main topics
+	RetryReaders []MessageReader // One per retry tier, shortest delay first
+	Router       *Router
+	Retries      *RetryQueue
+	DeadLetters  *DeadLetterQueue
+	Workers      int // Handlers running at once per reader; messages with the same key share one
+	MaxInFlight  int // Fetched but not yet committed messages per reader before fetching pauses
+
+	ShutdownTimeout time.Duration // How long in-flight handlers may run after cancel; 0 waits for them
+
+	mu      sync.Mutex
+	cancel  context.CancelFunc // Stops the running ConsumeMessages
+	running sync.WaitGroup
 }
 
 // ✅ NewKafkaConsumer: Handles connection retries and proper initialization
-func NewKafkaConsumer(brokers []string, groupID string, topics []string, userService *user.UserService, redisService *redis.RedisService) (*KafkaConsumer, error) {
+// The consumer subscribes to topics, usually topics.ConsumedBy for the service,
+// and to their retry topics; each must have a handler on router. Failed
+// messages are produced to retry and dead-letter topics through producer.
+func NewKafkaConsumer(brokers []string, groupID string, topics []string, router *Router, producer infrastructure.Producer) (*KafkaConsumer, error) {
 	maxRetries := 5
 
+	registered := make(map[string]bool)
+	for _, topic := range router.Topics() {
+		registered[topic] = true
+	}
+	for _, topic := range topics {
+		if !registered[topic] {
+			return nil, fmt.Errorf("no handler registered for topic %q", topic)
+		}
+	}
+
 	// ✅ Kafka Reader Configuration
+	// CommitInterval is left at zero, so CommitMessages commits synchronously
 	readerConfig := kafka.ReaderConfig{
-		Brokers:        brokers,
-		GroupID:        groupID,
-		GroupTopics:    topics,
-		MinBytes:       10e3, // 10KB
-		MaxBytes:       10e6, // 10MB
-		MaxWait:        1 * time.Second,
-		StartOffset:    kafka.FirstOffset,
-		CommitInterval: time.Second, // ✅ Automatically commit offsets
+		Brokers:     brokers,
+		GroupID:     groupID,
+		GroupTopics: topics,
+		MinBytes:    10e3, // 10KB
+		MaxBytes:    10e6, // 10MB
+		MaxWait:     1 * time.Second,
+		StartOffset: kafka.FirstOffset,
 	}
 
 	// ✅ Retry Connecting to Kafka
@@ -48,11 +81,25 @@ func NewKafkaConsumer(brokers []string, groupID string, topics []string, userSer
 		if err == nil {
 			conn.Close()
 			logrus.Infof("✅ Successfully connected to Kafka broker on attempt %d", attempt)
-			return &KafkaConsumer{
-				Reader:       kafka.NewReader(readerConfig),
-				UserService:  userService,
-				RedisService: redisService,
-			}, nil
+
+			consumer := &KafkaConsumer{
+				Reader:      kafka.NewReader(readerConfig),
+				Router:      router,
+				Retries:     NewRetryQueue(producer, DefaultRetryDelays),
+				DeadLetters: NewDeadLetterQueue(producer),
+				Workers:     config.GetEnvInt("KAFKA_CONSUMER_WORKERS", 8),
+				MaxInFlight: config.GetEnvInt("KAFKA_CONSUMER_MAX_IN_FLIGHT", 256),
+
+				ShutdownTimeout: config.GetEnvDuration("KAFKA_CONSUMER_SHUTDOWN_TIMEOUT", 30*time.Second),
+			}
+			// ✅ Each tier has its own group so its waiting never holds up the others
+			for tier := range consumer.Retries.Delays {
+				tierConfig := readerConfig
+				tierConfig.GroupID = groupID + ".retry." + catalog.DelayLabel(consumer.Retries.Delays[tier])
+				tierConfig.GroupTopics = consumer.Retries.Topics(readerConfig.GroupTopics, tier)
+				consumer.RetryReaders = append(consumer.RetryReaders, kafka.NewReader(tierConfig))
+			}
+			return consumer, nil
 		}
 
 		logrus.Warnf("⚠ Kafka connection attempt %d/%d failed: %v", attempt, maxRetries, err)
@@ -68,141 +115,255 @@ func NewKafkaConsumer(brokers []string, groupID string, topics []string, userSer
 	return nil, fmt.Errorf("unexpected error creating Kafka consumer")
 }
 
-// ✅ ConsumeMessages: Listens to Kafka and processes events
-func (c *KafkaConsumer) ConsumeMessages() {
+// ✅ ConsumeMessages: Listens to Kafka and processes events until ctx is cancelled
+// The retry tiers are consumed in their own goroutines. On cancel, fetching
+// stops, in-flight handlers get up to ShutdownTimeout to finish, and the offsets
+// of everything finished are committed before it returns. See
+// docs/kafka_delivery.md for the delivery guarantees.
+func (c *KafkaConsumer) ConsumeMessages(ctx context.Context) {
 	if c == nil || c.Reader == nil {
 		logrus.Error("❌ Kafka consumer is not properly initialized")
 		return
 	}
 
+	c.running.Add(1)
+	defer c.running.Done()
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	c.mu.Lock()
+	c.cancel = cancel
+	c.mu.Unlock()
+
 	logrus.Info("🚀 Kafka Consumer started and listening for messages...")
 
-	for {
-		msg, err := c.Reader.ReadMessage(context.Background())
-		if err != nil {
-			logrus.WithFields(logrus.Fields{"error": err}).Error("❌ Error reading Kafka message")
-			time.Sleep(10 * time.Second) // Prevent log spam on failure
-			continue
+	var wg sync.WaitGroup
+	for _, reader := range c.RetryReaders {
+		wg.Add(1)
+		go func(reader MessageReader) {
+			defer wg.Done()
+			c.consume(ctx, reader)
+		}(reader)
+	}
+	c.consume(ctx, c.Reader)
+	wg.Wait()
+	logrus.Info("🛑 Kafka Consumer stopped")
+}
+
+// ✅ consume: Fetches messages and hands them to a pool of workers, committing
+// each partition up to its highest offset with everything before it finished.
+// Messages with the same key always go to the same worker, so they are handled
+// in order. A crash before the commit leaves the offset where it was, so the
+// message is delivered again to whichever member picks up the partition.
+func (c *KafkaConsumer) consume(ctx context.Context, reader MessageReader) {
+	workers := max(c.Workers, 1)
+	maxInFlight := max(c.MaxInFlight, workers)
+
+	// ✅ Handlers outlive ctx by up to ShutdownTimeout, so in-flight work can finish
+	handlerCtx, cancelHandlers := context.WithCancel(context.WithoutCancel(ctx))
+	defer cancelHandlers()
+	stopDeadline := context.AfterFunc(ctx, func() {
+		if c.ShutdownTimeout > 0 {
+			time.AfterFunc(c.ShutdownTimeout, cancelHandlers)
 		}
+	})
+	defer stopDeadline()
+
+	tracker := NewOffsetTracker()
+	slots := make(chan struct{}, maxInFlight)
+	finished := make(chan kafka.Message, maxInFlight) // ✅ Never blocks: at most maxInFlight messages are out
+
+	var wg sync.WaitGroup
+	shards := make([]chan kafka.Message, workers)
+	for i := range shards {
+		shards[i] = make(chan kafka.Message, maxInFlight)
+		wg.Add(1)
+		go func(shard <-chan kafka.Message) {
+			defer wg.Done()
+			for msg := range shard {
+				if ctx.Err() != nil {
+					continue // ✅ Queued but not started: left uncommitted for the next member
+				}
+				if c.process(ctx, handlerCtx, newMessage(msg)) {
+					finished <- msg
+				}
+			}
+		}(shards[i])
+	}
 
-		logrus.Infof("\n📩 Kafka Message Received:\nTopic: %s\nValue: %s\n", msg.Topic, string(msg.Value))
+	committerDone := make(chan struct{})
+	go func() {
+		defer close(committerDone)
+		c.commitFinished(handlerCtx, reader, tracker, finished, slots)
+	}()
 
-		switch msg.Topic {
-		case "user.created":
-			c.handleUserCreate(msg.Value)
-		case "user.fetch":
-			c.handleUserFetchById(msg.Value)
-		case "user.read":
-			c.handlerUserFetchAll(msg.Value)
-		default:
-			logrus.Infof("⚠️ Unsupported Kafka message topic: %s", msg.Topic)
+fetch:
+	for {
+		msg, err := reader.FetchMessage(ctx)
+		if errors.Is(err, io.EOF) || ctx.Err() != nil {
+			break // ✅ The reader was closed or we are shutting down
+		}
+		if err != nil {
+			logrus.WithFields(logrus.Fields{"error": err}).Error("❌ Error reading Kafka message")
+			select {
+			case <-ctx.Done():
+			case <-time.After(10 * time.Second): // Prevent log spam on failure
+			}
 			continue
-
 		}
 
-		// ✅ Commit the message to prevent reprocessing
-		if err := c.Reader.CommitMessages(context.Background(), msg); err != nil {
-			logrus.WithFields(logrus.Fields{"error": err}).Error("❌ Failed to commit message")
+		// ✅ Blocks while MaxInFlight messages are uncommitted; the fetched message
+		// is left uncommitted if shutdown comes first
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+			break fetch
 		}
+		tracker.Start(msg)
+		shards[shardOf(msg, workers)] <- msg
 	}
-}
 
-func (c *KafkaConsumer) handleUserCreate(value []byte) {
-	var event events.KafkaUserCreatedEvent
-	err := json.Unmarshal(value, &event)
-	if err != nil {
-		logrus.WithFields(logrus.Fields{"error": err}).Error("❌ Error unmarshalling JSON message")
-		return
+	for _, shard := range shards {
+		close(shard)
 	}
+	go func() {
+		wg.Wait()
+		close(finished)
+	}()
+	<-committerDone
+}
 
-	// ✅ Extract User Data
-	firstName := event.Data.FirstName
-	lastName := event.Data.LastName
-	logrus.Infof("Extracted Data: FirstName=%s, LastName=%s", firstName, lastName)
+// ✅ commitFinished: Commits as messages finish, batching whatever finished meanwhile
+// Returns once every worker is done, or when handlerCtx ends, after committing
+// what finished so far. A failed commit means messages may be handled again,
+// never that they are lost.
+func (c *KafkaConsumer) commitFinished(handlerCtx context.Context, reader MessageReader, tracker *OffsetTracker, finished <-chan kafka.Message, slots <-chan struct{}) {
+	for {
+		released := 0
+		select {
+		case msg, ok := <-finished:
+			if !ok {
+				return
+			}
+			released = tracker.Done(msg)
+		case <-handlerCtx.Done():
+			logrus.Warn("⚠️ Shutdown deadline reached, abandoning in-flight Kafka messages")
+		}
 
-	// ✅ Validate Data
-	if firstName == "" || lastName == "" {
-		logrus.Error("❌ Invalid user data in Kafka message")
-		return
-	}
+	drain:
+		for {
+			select {
+			case msg, ok := <-finished:
+				if !ok {
+					break drain
+				}
+				released += tracker.Done(msg)
+			default:
+				break drain
+			}
+		}
 
-	// ✅ Call Service Layer to Process Business Logic
-	user, err := c.UserService.HandleUserCreated(firstName, lastName)
-	if err != nil {
-		logrus.WithFields(logrus.Fields{"error": err}).Error("❌ Failed to create user from Kafka event")
-		return
+		if commits := tracker.Commits(); len(commits) > 0 {
+			commitCtx, cancel := context.WithTimeout(context.WithoutCancel(handlerCtx), 10*time.Second)
+			if err := reader.CommitMessages(commitCtx, commits...); err != nil {
+				logrus.WithFields(logrus.Fields{"error": err, "partitions": len(commits)}).Error("❌ Failed to commit message")
+			}
+			cancel()
+		}
+		if handlerCtx.Err() != nil {
+			return
+		}
+		for i := 0; i < released; i++ {
+			<-slots
+		}
 	}
-
-	c.publishToRedis("user.created", user)
 }
 
-func (c *KafkaConsumer) handleUserFetchById(value []byte) {
-	var event events.KafkaUserFetchByIdEvent
-
-	err := json.Unmarshal(value, &event)
-	if err != nil {
-		logrus.WithFields(logrus.Fields{"error": err}).Error("❌ Error unmarshalling JSON message")
-		return
+// ✅ shardOf: Same key, same worker; keyless messages keep their partition's order
+func shardOf(msg kafka.Message, workers int) int {
+	h := fnv.New32a()
+	if len(msg.Key) > 0 {
+		h.Write(msg.Key)
+	} else {
+		h.Write([]byte(msg.Topic + "/" + strconv.Itoa(msg.Partition)))
 	}
+	return int(h.Sum32() % uint32(workers))
+}
 
-	userId := event.Data.UserID
-	logrus.Infof("Extracted Data: UserID=%d", userId)
-	if userId <= 0 {
-		logrus.Error("❌ Invalid user ID in Kafka message")
-		return
+// ✅ process: Handles msg once it is due; transient failures go to the next retry
+// tier, everything else that fails to the dead-letter topic. Returns true once
+// the message is in one of those places, false if shutdown came first.
+func (c *KafkaConsumer) process(ctx context.Context, handlerCtx context.Context, msg Message) bool {
+	msg.Attempt = 1
+	msg, notBefore := fromRetryTopic(msg)
+	msg.LastAttempt = c.Retries.LastAttempt(msg.Attempt)
+
+	// ✅ A tier's messages share one delay, so waiting for the head never delays a later one
+	if wait := time.Until(notBefore); wait > 0 {
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return false // ✅ Not started; the next member handles it when due
+		}
 	}
 
-	// ✅ Call the service with a clean integer (not raw JSON)
-	user, err := c.UserService.FetchUserById(userId)
-	if err != nil {
-		logrus.WithFields(logrus.Fields{"error": err}).Error("❌ Failed to fetch user from Kafka event")
-		return
+	handleErr := c.Router.Dispatch(handlerCtx, msg)
+	if handleErr == nil {
+		return true
 	}
-
-	c.publishToRedis("user.fetch", user)
-}
-
-func (c *KafkaConsumer) handlerUserFetchAll(value []byte) {
-	var event events.KafkaUserReadAllEvent
-
-	err := json.Unmarshal(value, &event)
-	if err != nil {
-		logrus.WithFields(logrus.Fields{"error": err}).Error("�� Error unmarshalling JSON message")
-		return
+	if handlerCtx.Err() != nil {
+		return false // ✅ Cut short by the shutdown deadline, not a failure of the message
 	}
-	users, err := c.UserService.HandleUserRead()
-	if err != nil {
-		logrus.WithFields(logrus.Fields{"error": err}).Error("�� Failed to fetch all users from Kafka event")
-		return
+	if msg.WillRetry(handleErr) {
+		return c.mustProduce(handlerCtx, msg, "retry", func() error {
+			_, err := c.Retries.Schedule(msg, handleErr)
+			return err
+		})
 	}
-
-	c.publishToRedis("user.read", users)
+	return c.mustProduce(handlerCtx, msg, "dead-letter", func() error {
+		return c.DeadLetters.Publish(msg, msg.Attempt, handleErr)
+	})
 }
 
-// ✅ Publish to Redis (Reusable function)
-func (c *KafkaConsumer) publishToRedis(event string, data interface{}) {
-	// ✅ Convert data to JSON
-	userData, err := json.Marshal(data)
-	if err != nil {
-		logrus.WithFields(logrus.Fields{"error": err}).Errorf("❌ Failed to marshal data for event: %s", event)
-		return
+// ✅ mustProduce: Keeps trying until it succeeds or ctx ends, since committing
+// without it would lose the message
+func (c *KafkaConsumer) mustProduce(ctx context.Context, msg Message, action string, produce func() error) bool {
+	fields := logrus.Fields{
+		"topic":     msg.Topic,
+		"partition": msg.Partition,
+		"offset":    msg.Offset,
+		"attempt":   msg.Attempt,
 	}
-
-	// ✅ Publish asynchronously to Redis
-	go func() {
-		err := c.RedisService.Publish(RedisChannel, string(userData))
-		if err != nil {
-			logrus.WithFields(logrus.Fields{"error": err}).Errorf("❌ Failed to publish data to Redis for event: %s", event)
-		} else {
-			logrus.Infof("✅ Successfully published event [%s] to Redis: %s", event, userData)
+	for {
+		err := produce()
+		if err == nil {
+			logrus.WithFields(fields).Warnf("⚠️ Kafka message sent to %s", action)
+			return true
 		}
-	}()
+		logrus.WithFields(fields).WithFields(logrus.Fields{"error": err}).Errorf("❌ Failed to %s Kafka message, retrying", action)
+		select {
+		case <-time.After(5 * time.Second):
+		case <-ctx.Done():
+			return false
+		}
+	}
 }
 
-// ✅ Close: Gracefully shuts down the Kafka consumer
+// ✅ Close: Stops ConsumeMessages, waits for it to commit final offsets, then closes the readers
 func (c *KafkaConsumer) Close() error {
-	if c != nil && c.Reader != nil {
-		return c.Reader.Close()
+	if c == nil || c.Reader == nil {
+		return nil
+	}
+	c.mu.Lock()
+	cancel := c.cancel
+	c.mu.Unlock()
+	if cancel != nil {
+		cancel()
+	}
+	c.running.Wait()
+
+	errs := []error{c.Reader.Close()}
+	for _, reader := range c.RetryReaders {
+		errs = append(errs, reader.Close())
 	}
-	return nil
+	return errors.Join(errs...)
 }
@@ -0,0 +1,198 @@
+package consumers
+
+import (
+	"GoSyntaxDoc/infrastructure"
+	"container/list"
+	"context"
+	"fmt"
+	"runtime/debug"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ✅ Logging: One line per message with its outcome and duration
+func Logging() Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, msg Message) error {
+			start := time.Now()
+			err := next(ctx, msg)
+
+			entry := logrus.WithFields(logrus.Fields{
+				"topic":          msg.Topic,
+				"partition":      msg.Partition,
+				"offset":         msg.Offset,
+				"event_id":       msg.Headers.Get(infrastructure.HeaderEventID),
+				"correlation_id": msg.Headers.Get(infrastructure.HeaderCorrelationID),
+				"traceparent":    msg.Headers.Get(infrastructure.HeaderTraceparent),
+				"duration":       time.Since(start).String(),
+			})
+			if err != nil {
+				entry.WithFields(logrus.Fields{"error": err}).Error("❌ Kafka message handling failed")
+			} else {
+				entry.Info("✅ Kafka message handled")
+			}
+			return err
+		}
+	}
+}
+
+// ✅ PanicError - A handler panicked; the consumer keeps running
+type PanicError struct {
+	Value interface{}
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("handler panic: %v", e.Value)
+}
+
+// ✅ Recovery: Turns a handler panic into a *PanicError
+func Recovery() Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, msg Message) (err error) {
+			defer func() {
+				if recovered := recover(); recovered != nil {
+					err = &PanicError{Value: recovered, Stack: debug.Stack()}
+				}
+			}()
+			return next(ctx, msg)
+		}
+	}
+}
+
+// ✅ TopicStats - Counters for one topic
+type TopicStats struct {
+	Handled  int64
+	Failed   int64
+	Duration time.Duration // Total time spent in handlers
+}
+
+// ✅ Metrics - In-process counters per topic, filled by the Metrics middleware
+type Metrics struct {
+	mu     sync.Mutex
+	topics map[string]*TopicStats
+}
+
+func NewMetrics() *Metrics {
+	return &Metrics{topics: make(map[string]*TopicStats)}
+}
+
+func (m *Metrics) observe(topic string, duration time.Duration, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	stats, ok := m.topics[topic]
+	if !ok {
+		stats = &TopicStats{}
+		m.topics[topic] = stats
+	}
+	if err != nil {
+		stats.Failed++
+	} else {
+		stats.Handled++
+	}
+	stats.Duration += duration
+}
+
+// ✅ Snapshot: A copy of the counters by topic
+func (m *Metrics) Snapshot() map[string]TopicStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	snapshot := make(map[string]TopicStats, len(m.topics))
+	for topic, stats := range m.topics {
+		snapshot[topic] = *stats
+	}
+	return snapshot
+}
+
+// ✅ Log: Writes the counters, e.g. on shutdown
+func (m *Metrics) Log() {
+	snapshot := m.Snapshot()
+	topics := make([]string, 0, len(snapshot))
+	for topic := range snapshot {
+		topics = append(topics, topic)
+	}
+	sort.Strings(topics)
+	for _, topic := range topics {
+		stats := snapshot[topic]
+		logrus.WithFields(logrus.Fields{
+			"topic":    topic,
+			"handled":  stats.Handled,
+			"failed":   stats.Failed,
+			"duration": stats.Duration.String(),
+		}).Info("📊 Kafka consumer metrics")
+	}
+}
+
+// ✅ WithMetrics: Records every message's outcome in m
+func WithMetrics(m *Metrics) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, msg Message) error {
+			start := time.Now()
+			err := next(ctx, msg)
+			m.observe(msg.Topic, time.Since(start), err)
+			return err
+		}
+	}
+}
+
+// ✅ DedupeCache - The most recently handled event IDs, bounded to Size entries
+type DedupeCache struct {
+	mu    sync.Mutex
+	size  int
+	order *list.List // Front is the most recent
+	seen  map[string]*list.Element
+}
+
+func NewDedupeCache(size int) *DedupeCache {
+	return &DedupeCache{size: size, order: list.New(), seen: make(map[string]*list.Element)}
+}
+
+func (d *DedupeCache) Contains(id string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	_, ok := d.seen[id]
+	return ok
+}
+
+func (d *DedupeCache) Add(id string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if element, ok := d.seen[id]; ok {
+		d.order.MoveToFront(element)
+		return
+	}
+	d.seen[id] = d.order.PushFront(id)
+	if d.order.Len() > d.size {
+		oldest := d.order.Back()
+		d.order.Remove(oldest)
+		delete(d.seen, oldest.Value.(string))
+	}
+}
+
+// ✅ Dedupe: Skips messages whose event-id header was already handled successfully
+// The cache lives in memory, so this only catches redeliveries seen by this process.
+// Messages without an event ID are always handled. Don't put it in front of
+// handlers backed by the processed_events ledger: they answer a duplicate with
+// the earlier result, which a skipped message never gets.
+func Dedupe(cache *DedupeCache) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, msg Message) error {
+			id := msg.Headers.Get(infrastructure.HeaderEventID)
+			if id == "" {
+				return next(ctx, msg)
+			}
+			if cache.Contains(id) {
+				logrus.WithFields(logrus.Fields{"topic": msg.Topic, "event_id": id}).Info("♻️ Skipping duplicate Kafka message")
+				return nil
+			}
+			if err := next(ctx, msg); err != nil {
+				return err
+			}
+			cache.Add(id)
+			return nil
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package consumers
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/segmentio/kafka-go"
+)
+
+type topicPartition struct {
+	topic     string
+	partition int
+}
+
+// partitionOffsets - Fetched offsets of one partition, oldest first, and which are done
+type partitionOffsets struct {
+	fetched   []int64
+	done      map[int64]bool
+	committed int64 // Highest offset returned by Commits; -1 before the first
+	safe      int64 // Highest offset with everything up to it done; -1 before the first
+}
+
+// ✅ OffsetTracker - Finds the highest offset per partition that is safe to commit
+// when messages finish out of order: an offset is safe once it and every offset
+// fetched before it on the same partition are done.
+type OffsetTracker struct {
+	mu         sync.Mutex
+	partitions map[topicPartition]*partitionOffsets
+}
+
+func NewOffsetTracker() *OffsetTracker {
+	return &OffsetTracker{partitions: make(map[topicPartition]*partitionOffsets)}
+}
+
+// ✅ Start: Records a fetched message; call in fetch order
+func (t *OffsetTracker) Start(msg kafka.Message) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	key := topicPartition{msg.Topic, msg.Partition}
+	p, ok := t.partitions[key]
+	if !ok {
+		p = &partitionOffsets{done: make(map[int64]bool), committed: -1, safe: -1}
+		t.partitions[key] = p
+	}
+	p.fetched = append(p.fetched, msg.Offset)
+}
+
+// ✅ Done: Marks a started message finished; returns how many messages became
+// safe to commit, and so no longer count as in flight
+func (t *OffsetTracker) Done(msg kafka.Message) int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	p, ok := t.partitions[topicPartition{msg.Topic, msg.Partition}]
+	if !ok {
+		return 0
+	}
+	p.done[msg.Offset] = true
+
+	released := 0
+	for len(p.fetched) > 0 && p.done[p.fetched[0]] {
+		p.safe = p.fetched[0]
+		delete(p.done, p.fetched[0])
+		p.fetched = p.fetched[1:]
+		released++
+	}
+	return released
+}
+
+// ✅ Commits: One message per partition whose safe offset moved since the last call,
+// ready for CommitMessages (which commits the offset after it)
+func (t *OffsetTracker) Commits() []kafka.Message {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var commits []kafka.Message
+	for key, p := range t.partitions {
+		if p.safe > p.committed {
+			p.committed = p.safe
+			commits = append(commits, kafka.Message{Topic: key.topic, Partition: key.partition, Offset: p.safe})
+		}
+	}
+	sort.Slice(commits, func(i, j int) bool {
+		if commits[i].Topic != commits[j].Topic {
+			return commits[i].Topic < commits[j].Topic
+		}
+		return commits[i].Partition < commits[j].Partition
+	})
+	return commits
+}
@@ -0,0 +1,163 @@
+package consumers
+
+import (
+	"GoSyntaxDoc/domain/events"
+	"GoSyntaxDoc/infrastructure"
+	"GoSyntaxDoc/infrastructure/redis"
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+const RedisChannel = events.ReplyChannel
+
+// ✅ Publisher - The part of *redis.RedisService replies are sent through
+type Publisher interface {
+	Publish(channel string, message string) error
+}
+
+var _ Publisher = (*redis.RedisService)(nil)
+
+// ✅ Replies - Publishes command results to Redis, where the gateway routes them to clients
+type Replies struct {
+	RedisService Publisher
+	inFlight     sync.WaitGroup // Publishes not yet acknowledged by Redis
+}
+
+func NewReplies(redisService Publisher) *Replies {
+	return &Replies{RedisService: redisService}
+}
+
+// ✅ replyTo - Where the result of a command goes
+type replyTo struct {
+	ConnectionID  string
+	CorrelationID string
+}
+
+// ✅ replyRoute: Headers win; the body's metadata covers messages produced without them
+func replyRoute(headers infrastructure.Headers, meta events.EventMetadata) replyTo {
+	route := replyTo{
+		ConnectionID:  headers.Get(infrastructure.HeaderConnectionID),
+		CorrelationID: headers.Get(infrastructure.HeaderCorrelationID),
+	}
+	if route.ConnectionID == "" {
+		route.ConnectionID = meta.ConnectionID
+	}
+	if route.CorrelationID == "" {
+		route.CorrelationID = meta.RequestID
+	}
+	return route
+}
+
+// ✅ eventIDOf: The command's event ID, from its header or, failing that, its body
+func eventIDOf(headers infrastructure.Headers, meta events.EventMetadata) string {
+	if id := headers.Get(infrastructure.HeaderEventID); id != "" {
+		return id
+	}
+	return meta.EventID
+}
+
+// ✅ originHeaders: The command's headers with the reply route and event ID filled in,
+// for commands produced before the gateway set headers
+func originHeaders(headers infrastructure.Headers, route replyTo, eventID string) infrastructure.Headers {
+	origin := make(infrastructure.Headers, len(headers)+3)
+	for name, value := range headers {
+		origin[name] = value
+	}
+	if eventID != "" {
+		origin[infrastructure.HeaderEventID] = eventID
+	}
+	if route.ConnectionID != "" {
+		origin[infrastructure.HeaderConnectionID] = route.ConnectionID
+	}
+	if route.CorrelationID != "" {
+		origin[infrastructure.HeaderCorrelationID] = route.CorrelationID
+	}
+	return origin
+}
+
+// ✅ Publish to Redis (Reusable function)
+// The message carries the originating connection ID so the gateway can deliver
+// the envelope only to the client that issued the command.
+func (r *Replies) publishToRedis(route replyTo, event string, eventType string, data interface{}) {
+	r.publish(RedisChannel, events.RedisMessage{
+		ConnectionID: route.ConnectionID,
+		Envelope:     events.NewEnvelope(event, eventType, route.CorrelationID, data),
+	})
+}
+
+// ✅ publishFailure: Sends "<type>.failed" to the issuing connection so the client stops waiting
+func (r *Replies) publishFailure(route replyTo, event string, eventType string, reason string, message string) {
+	r.publish(RedisChannel, events.RedisMessage{
+		ConnectionID: route.ConnectionID,
+		Envelope:     events.NewFailureEnvelope(event, eventType, route.CorrelationID, reason, message),
+	})
+}
+
+// ✅ publishDecodeFailure: The payload didn't match the event type, but the
+// routing metadata may still be readable, in which case the client is told
+func (r *Replies) publishDecodeFailure(msg Message, event string, eventType string, err error) {
+	var meta events.EventMetadata
+	_ = json.Unmarshal(msg.Value, &meta)
+	route := replyRoute(msg.Headers, meta)
+	if route.ConnectionID == "" {
+		return
+	}
+	r.publishFailure(route, event, eventType, events.ReasonInvalidPayload, err.Error())
+}
+
+// ✅ replyOnDecodeFailure: Route middleware telling the client when a Typed handler couldn't decode its command
+func (r *Replies) replyOnDecodeFailure(event string, eventType string) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, msg Message) error {
+			err := next(ctx, msg)
+			var decodeErr *DecodeError
+			if errors.As(err, &decodeErr) {
+				r.publishDecodeFailure(msg, event, eventType, decodeErr.Err)
+			}
+			return err
+		}
+	}
+}
+
+func (r *Replies) publish(channel string, message events.RedisMessage) {
+	name := message.Envelope.Name()
+
+	// ✅ Convert message to JSON
+	userData, err := json.Marshal(message)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{"error": err}).Errorf("❌ Failed to marshal data for event: %s", name)
+		return
+	}
+
+	// ✅ Publish asynchronously to Redis
+	r.inFlight.Add(1)
+	go func() {
+		defer r.inFlight.Done()
+		err := r.RedisService.Publish(channel, string(userData))
+		if err != nil {
+			logrus.WithFields(logrus.Fields{"error": err}).Errorf("❌ Failed to publish data to Redis for event: %s", name)
+		} else {
+			logrus.Infof("✅ Successfully published event [%s] to Redis channel %s: %s", name, channel, userData)
+		}
+	}()
+}
+
+// ✅ Wait: Blocks until every publish started so far is done, or ctx ends
+func (r *Replies) Wait(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		r.inFlight.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,105 @@
+package consumers
+
+import (
+	"GoSyntaxDoc/domain/topics"
+	"GoSyntaxDoc/infrastructure"
+	"strconv"
+	"time"
+)
+
+// ✅ Headers on messages in a retry topic
+const (
+	HeaderRetryTopic     = "retry-original-topic"
+	HeaderRetryPartition = "retry-original-partition"
+	HeaderRetryOffset    = "retry-original-offset"
+	HeaderRetryAttempt   = "retry-attempt"    // Failed attempts so far
+	HeaderRetryNotBefore = "retry-not-before" // Unix milliseconds; the retry consumer waits until then
+	HeaderRetryError     = "retry-error"
+)
+
+// ✅ DefaultRetryDelays: user.created → user.created.retry.10s → .retry.1m → .retry.10m → user.created.dlq
+var DefaultRetryDelays = topics.RetryDelays
+
+// ✅ RetryTopic: e.g. RetryTopic("user.created", time.Minute) is "user.created.retry.1m"
+func RetryTopic(topic string, delay time.Duration) string {
+	return topics.Retry(topic, delay)
+}
+
+// ✅ RetryQueue - Schedules transient failures onto the retry topic for their attempt
+// Each tier is consumed separately, so a message waiting out its delay never
+// blocks the main topic's partition.
+type RetryQueue struct {
+	Producer infrastructure.Producer
+	Delays   []time.Duration // One retry topic per delay, shortest first
+}
+
+func NewRetryQueue(producer infrastructure.Producer, delays []time.Duration) *RetryQueue {
+	return &RetryQueue{Producer: producer, Delays: delays}
+}
+
+// ✅ Topics: The retry topics of one tier for the given main topics
+func (q *RetryQueue) Topics(topics []string, tier int) []string {
+	retryTopics := make([]string, len(topics))
+	for i, topic := range topics {
+		retryTopics[i] = RetryTopic(topic, q.Delays[tier])
+	}
+	return retryTopics
+}
+
+// ✅ LastAttempt: True when a failure of attempt goes to the dead-letter topic
+func (q *RetryQueue) LastAttempt(attempt int) bool {
+	return attempt > len(q.Delays)
+}
+
+// ✅ Schedule: Sends msg to the tier after its attempt; false if it has none left
+func (q *RetryQueue) Schedule(msg Message, cause error) (bool, error) {
+	if q.LastAttempt(msg.Attempt) {
+		return false, nil
+	}
+	delay := q.Delays[msg.Attempt-1]
+
+	headers := make(infrastructure.Headers, len(msg.Headers)+6)
+	for name, value := range msg.Headers {
+		headers[name] = value
+	}
+	headers[HeaderRetryTopic] = msg.Topic
+	headers[HeaderRetryPartition] = strconv.Itoa(msg.Partition)
+	headers[HeaderRetryOffset] = strconv.FormatInt(msg.Offset, 10)
+	headers[HeaderRetryAttempt] = strconv.Itoa(msg.Attempt)
+	headers[HeaderRetryNotBefore] = strconv.FormatInt(time.Now().Add(delay).UnixMilli(), 10)
+	headers[HeaderRetryError] = cause.Error()
+
+	err := q.Producer.Produce(infrastructure.Message{
+		Topic:   RetryTopic(msg.Topic, delay),
+		Key:     string(msg.Key),
+		Value:   msg.Value,
+		Headers: headers,
+	})
+	return err == nil, err
+}
+
+// ✅ fromRetryTopic: Restores the original topic and coordinates of a retried message,
+// and returns when it may run; messages read from a main topic are returned as is
+func fromRetryTopic(msg Message) (Message, time.Time) {
+	topic := msg.Headers.Get(HeaderRetryTopic)
+	if topic == "" {
+		return msg, time.Time{}
+	}
+
+	msg.Topic = topic
+	if partition, err := strconv.Atoi(msg.Headers.Get(HeaderRetryPartition)); err == nil {
+		msg.Partition = partition
+	}
+	if offset, err := strconv.ParseInt(msg.Headers.Get(HeaderRetryOffset), 10, 64); err == nil {
+		msg.Offset = offset
+	}
+	if failed, err := strconv.Atoi(msg.Headers.Get(HeaderRetryAttempt)); err == nil {
+		msg.Attempt = failed + 1
+	}
+
+	var notBefore time.Time
+	if millis, err := strconv.ParseInt(msg.Headers.Get(HeaderRetryNotBefore), 10, 64); err == nil {
+		notBefore = time.UnixMilli(millis)
+	}
+	return msg, notBefore
+}
@@ -0,0 +1,140 @@
+package consumers
+
+import (
+	"GoSyntaxDoc/infrastructure"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+var ErrNoHandler = errors.New("no handler registered for topic")
+
+// ✅ Message - What handlers receive: a Kafka message with its headers decoded
+type Message struct {
+	Topic     string
+	Partition int
+	Offset    int64
+	Key       []byte
+	Value     []byte
+	Headers   infrastructure.Headers
+	Time      time.Time
+
+	Attempt     int  // 1 on the first delivery, counting retries through the retry topics
+	LastAttempt bool // No retry follows if this attempt fails
+}
+
+// ✅ WillRetry: Whether the consumer retries msg after a handler returns err,
+// e.g. to hold back the failure reply until the last attempt
+func (m Message) WillRetry(err error) bool {
+	return err != nil && !m.LastAttempt && IsTransient(err)
+}
+
+func newMessage(msg kafka.Message) Message {
+	return Message{
+		Topic:     msg.Topic,
+		Partition: msg.Partition,
+		Offset:    msg.Offset,
+		Key:       msg.Key,
+		Value:     msg.Value,
+		Headers:   infrastructure.HeadersFromKafka(msg.Headers),
+		Time:      msg.Time,
+	}
+}
+
+// ✅ HandlerFunc - Processes one message; a returned error means it was not handled
+type HandlerFunc func(ctx context.Context, msg Message) error
+
+// ✅ Middleware - Wraps a handler, e.g. for logging or recovery
+type Middleware func(HandlerFunc) HandlerFunc
+
+// ✅ DecodeError - The message body didn't match the handler's event type
+type DecodeError struct {
+	Topic string
+	Err   error
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("decode %s message: %v", e.Topic, e.Err)
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// ✅ Typed: Decodes the JSON body into T before calling handle, e.g. Typed(h.handleUserFetch)
+func Typed[T any](handle func(ctx context.Context, msg Message, event T) error) HandlerFunc {
+	return func(ctx context.Context, msg Message) error {
+		var event T
+		if err := json.Unmarshal(msg.Value, &event); err != nil {
+			return &DecodeError{Topic: msg.Topic, Err: err}
+		}
+		return handle(ctx, msg, event)
+	}
+}
+
+// ✅ Router - Topic → handler table the consumer dispatches through
+// Middlewares added with Use wrap every handler, outermost first; those passed
+// to Register wrap only that topic's handler, inside the shared ones.
+type Router struct {
+	mu          sync.RWMutex
+	handlers    map[string]HandlerFunc
+	middlewares []Middleware
+}
+
+func NewRouter() *Router {
+	return &Router{handlers: make(map[string]HandlerFunc)}
+}
+
+// ✅ Use: Adds middlewares applied to every topic, including ones already registered
+func (r *Router) Use(middlewares ...Middleware) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.middlewares = append(r.middlewares, middlewares...)
+}
+
+// ✅ Register: Routes topic to handler, replacing any previous handler
+func (r *Router) Register(topic string, handler HandlerFunc, middlewares ...Middleware) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.handlers[topic] = chain(handler, middlewares)
+}
+
+// ✅ Topics: Every registered topic, sorted; what the consumer subscribes to
+func (r *Router) Topics() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	topics := make([]string, 0, len(r.handlers))
+	for topic := range r.handlers {
+		topics = append(topics, topic)
+	}
+	sort.Strings(topics)
+	return topics
+}
+
+// ✅ Dispatch: Runs the topic's handler through the middleware chain
+func (r *Router) Dispatch(ctx context.Context, msg Message) error {
+	r.mu.RLock()
+	handler, ok := r.handlers[msg.Topic]
+	middlewares := r.middlewares
+	r.mu.RUnlock()
+
+	if !ok {
+		handler = func(context.Context, Message) error {
+			return fmt.Errorf("%w: %s", ErrNoHandler, msg.Topic)
+		}
+	}
+	return chain(handler, middlewares)(ctx, msg)
+}
+
+func chain(handler HandlerFunc, middlewares []Middleware) HandlerFunc {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		handler = middlewares[i](handler)
+	}
+	return handler
+}
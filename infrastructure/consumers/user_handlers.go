@@ -0,0 +1,108 @@
+package consumers
+
+import (
+	"GoSyntaxDoc/domain/events"
+	"GoSyntaxDoc/domain/topics"
+	"GoSyntaxDoc/services/user"
+	"context"
+	"errors"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ✅ UserHandlers - Handles the user commands produced by the WebSocket gateway
+type UserHandlers struct {
+	UserService *user.UserService
+	Replies     *Replies
+}
+
+func NewUserHandlers(userService *user.UserService, replies *Replies) *UserHandlers {
+	return &UserHandlers{UserService: userService, Replies: replies}
+}
+
+// ✅ Register: Routes the user topics to their handlers
+func (h *UserHandlers) Register(r *Router) {
+	r.Register(topics.UserCreated, Typed(h.handleUserCreate), h.Replies.replyOnDecodeFailure("user", "created"))
+	r.Register(topics.UserFetch, Typed(h.handleUserFetchById), h.Replies.replyOnDecodeFailure("user", "fetch"))
+	r.Register(topics.UserRead, Typed(h.handleUserFetchAll), h.Replies.replyOnDecodeFailure("user", "read"))
+}
+
+func (h *UserHandlers) handleUserCreate(ctx context.Context, msg Message, event events.KafkaUserCreatedEvent) error {
+	route := replyRoute(msg.Headers, event.EventMetadata)
+
+	// ✅ Extract User Data
+	firstName := event.Data.FirstName
+	lastName := event.Data.LastName
+	logrus.Infof("Extracted Data: FirstName=%s, LastName=%s", firstName, lastName)
+
+	// ✅ Validate Data
+	if firstName == "" || lastName == "" {
+		logrus.Error("❌ Invalid user data in Kafka message")
+		h.Replies.publishFailure(route, "user", "created", events.ReasonValidation, user.ErrInvalidUserData.Error())
+		return nil
+	}
+
+	// ✅ The reply and broadcast are sent by the outbox relay once the user is committed
+	origin := originHeaders(msg.Headers, route, eventIDOf(msg.Headers, event.EventMetadata))
+	user, created, err := h.UserService.HandleUserCreated(firstName, lastName, origin)
+	if err != nil {
+		return h.publishServiceFailure(msg, route, "created", err)
+	}
+	if !created {
+		// ✅ A redelivered command: answer again in case the first reply was missed
+		h.Replies.publishToRedis(route, "user", "created", user)
+	}
+	return nil
+}
+
+func (h *UserHandlers) handleUserFetchById(ctx context.Context, msg Message, event events.KafkaUserFetchByIdEvent) error {
+	route := replyRoute(msg.Headers, event.EventMetadata)
+
+	userId := event.Data.UserID
+	logrus.Infof("Extracted Data: UserID=%d", userId)
+	if userId <= 0 {
+		logrus.Error("❌ Invalid user ID in Kafka message")
+		h.Replies.publishFailure(route, "user", "fetch", events.ReasonValidation, user.ErrInvalidUserID.Error())
+		return nil
+	}
+
+	// ✅ Call the service with a clean integer (not raw JSON)
+	user, err := h.UserService.FetchUserById(userId)
+	if err != nil {
+		return h.publishServiceFailure(msg, route, "fetch", err)
+	}
+
+	h.Replies.publishToRedis(route, "user", "fetch", user)
+	return nil
+}
+
+func (h *UserHandlers) handleUserFetchAll(ctx context.Context, msg Message, event events.KafkaUserReadAllEvent) error {
+	route := replyRoute(msg.Headers, event.EventMetadata)
+
+	users, err := h.UserService.HandleUserRead()
+	if err != nil {
+		return h.publishServiceFailure(msg, route, "read", err)
+	}
+
+	h.Replies.publishToRedis(route, "user", "read", users)
+	return nil
+}
+
+// ✅ publishServiceFailure: Maps service errors to reason codes without leaking internals
+// Rejections the client caused are handled; anything else is returned as a handler
+// failure and reported to the client unless the consumer will retry it.
+func (h *UserHandlers) publishServiceFailure(msg Message, route replyTo, eventType string, err error) error {
+	switch {
+	case errors.Is(err, user.ErrUserNotFound):
+		h.Replies.publishFailure(route, "user", eventType, events.ReasonNotFound, err.Error())
+		return nil
+	case errors.Is(err, user.ErrInvalidUserID), errors.Is(err, user.ErrInvalidUserData):
+		h.Replies.publishFailure(route, "user", eventType, events.ReasonValidation, err.Error())
+		return nil
+	default:
+		if !msg.WillRetry(err) {
+			h.Replies.publishFailure(route, "user", eventType, events.ReasonInternal, "the request could not be processed")
+		}
+		return err
+	}
+}
@@ -4,4 +4,6 @@ func InitDB() {
 	InitUser()
 	InitProduct()
 	InitOrder()
+	InitOutbox()
+	InitProcessedEvents()
 }
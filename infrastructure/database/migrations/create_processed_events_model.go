@@ -0,0 +1,25 @@
+package migrations
+
+import (
+	"GoSyntaxDoc/infrastructure/database"
+	"context"
+	"log"
+)
+
+// ✅ InitProcessedEvents: One row per event a consumer has applied, written in the
+// same transaction as the consumer's changes; result is what it answered
+func InitProcessedEvents() {
+	ctx := context.Background()
+
+	_, err := database.Database.DB.Exec(ctx,
+		`CREATE TABLE IF NOT EXISTS processed_events (
+            consumer VARCHAR(255) NOT NULL,
+            event_id VARCHAR(255) NOT NULL,
+            result JSONB,
+            processed_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+            PRIMARY KEY (consumer, event_id))`)
+	if err != nil {
+		log.Fatalf("Error creating processed_events table: %v", err)
+	}
+	log.Println("Processed events table created successfully")
+}
@@ -0,0 +1,66 @@
+package infrastructure
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"sort"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// ✅ Standard headers set on every message produced for a client command
+const (
+	HeaderEventID       = "event-id"       // Unique per message
+	HeaderCorrelationID = "correlation-id" // Ties replies and follow-up events to the command
+	HeaderRequestID     = "request-id"     // Client-supplied request ID, if any
+	HeaderSchemaVersion = "schema-version" // Version of the body's schema
+	HeaderContentType   = "content-type"   // Encoding of the body
+	HeaderConnectionID  = "connection-id"  // Gateway connection that issued the command
+	HeaderUserSubject   = "user-subject"   // Authenticated subject
+	HeaderUserTenant    = "user-tenant"    // Authenticated tenant
+	HeaderTraceparent   = "traceparent"    // W3C trace context
+)
+
+const ContentTypeJSON = "application/json"
+
+// ✅ Headers - Kafka message headers by name
+type Headers map[string]string
+
+// ✅ HeadersFromKafka: Later values win if a header is repeated
+func HeadersFromKafka(headers []kafka.Header) Headers {
+	h := make(Headers, len(headers))
+	for _, header := range headers {
+		h[header.Key] = string(header.Value)
+	}
+	return h
+}
+
+// ✅ Kafka converts to kafka-go headers, sorted by name for stable output
+func (h Headers) Kafka() []kafka.Header {
+	if len(h) == 0 {
+		return nil
+	}
+	names := make([]string, 0, len(h))
+	for name := range h {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	headers := make([]kafka.Header, 0, len(h))
+	for _, name := range names {
+		headers = append(headers, kafka.Header{Key: name, Value: []byte(h[name])})
+	}
+	return headers
+}
+
+// ✅ Get returns the header value, or "" when absent (also on a nil map)
+func (h Headers) Get(name string) string {
+	return h[name]
+}
+
+// ✅ NewTraceparent starts a new sampled W3C trace: version-traceid-spanid-flags
+func NewTraceparent() string {
+	var ids [24]byte
+	_, _ = rand.Read(ids[:])
+	return "00-" + hex.EncodeToString(ids[:16]) + "-" + hex.EncodeToString(ids[16:]) + "-01"
+}
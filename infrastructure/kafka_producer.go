@@ -1,58 +1,264 @@
 package infrastructure
 
 import (
+	"GoSyntaxDoc/config"
 	"GoSyntaxDoc/presentation/middleware"
 	"context"
+	"errors"
+	"sync"
 	"time"
 
 	"github.com/segmentio/kafka-go"
 	"github.com/sirupsen/logrus"
 )
 
+var ErrProducerClosed = errors.New("kafka producer is closed")
+
+// ✅ KafkaProducerConfig - Batching and compression settings shared by every topic writer
+type KafkaProducerConfig struct {
+	Brokers      []string
+	BatchSize    int               // Messages per partition batch
+	BatchBytes   int64             // Upper bound on a batch's size in bytes
+	Linger       time.Duration     // How long a partial batch waits for more messages
+	Compression  kafka.Compression // None, Snappy, Lz4, Zstd or Gzip
+	WriteTimeout time.Duration     // Upper bound on a single produce request to the broker
+	MaxInFlight  int               // Messages awaiting delivery before Produce and ProduceAsync block
+
+	Transport kafka.RoundTripper // Nil uses a new kafka.Transport; tests substitute a fake broker
+}
+
+func DefaultKafkaProducerConfig(brokers []string) KafkaProducerConfig {
+	return KafkaProducerConfig{
+		Brokers:      brokers,
+		BatchSize:    100,
+		BatchBytes:   1 << 20, // 1MB
+		Linger:       10 * time.Millisecond,
+		Compression:  kafka.Snappy,
+		WriteTimeout: 5 * time.Second,
+		MaxInFlight:  1000,
+	}
+}
+
+// ✅ KafkaProducerConfigFromEnv: Defaults overridden by KAFKA_* environment variables
+func KafkaProducerConfigFromEnv(brokers []string) (KafkaProducerConfig, error) {
+	cfg := DefaultKafkaProducerConfig(brokers)
+	cfg.BatchSize = config.GetEnvInt("KAFKA_BATCH_SIZE", cfg.BatchSize)
+	cfg.BatchBytes = int64(config.GetEnvInt("KAFKA_BATCH_BYTES", int(cfg.BatchBytes)))
+	cfg.Linger = config.GetEnvDuration("KAFKA_LINGER", cfg.Linger)
+	cfg.WriteTimeout = config.GetEnvDuration("KAFKA_WRITE_TIMEOUT", cfg.WriteTimeout)
+	cfg.MaxInFlight = config.GetEnvInt("KAFKA_MAX_IN_FLIGHT", cfg.MaxInFlight)
+	if codec := config.GetEnv("KAFKA_COMPRESSION", ""); codec != "" {
+		if err := cfg.Compression.UnmarshalText([]byte(codec)); err != nil {
+			return cfg, err
+		}
+	}
+	return cfg, nil
+}
+
+// ✅ KafkaProducer - Keeps one long-lived, batching writer per topic
+// Writers run in kafka-go's async mode: every message is queued on its
+// partition in call order and each partition's batches are written one at a
+// time, so messages with the same key reach the broker in the order they were
+// produced.
 type KafkaProducer struct {
-	Brokers []string
+	config    KafkaProducerConfig
+	transport kafka.RoundTripper // Shared connection pool for all writers
+	slots     chan struct{}      // Bounds messages awaiting delivery
+
+	mu      sync.Mutex
+	idle    *sync.Cond // Signalled when pending drops to zero
+	writers map[string]*kafka.Writer
+	pending int // Messages queued and not yet reported
+	closed  bool
+}
+
+// delivery travels with a message through the writer, in kafka.Message.WriterData
+type delivery struct {
+	msg        Message
+	onDelivery func(DeliveryReport)
 }
 
 // ✅ Constructor Function (Dependency Injection)
-func NewKafkaProducer(brokers []string) *KafkaProducer {
-	return &KafkaProducer{
-		Brokers: brokers,
+func NewKafkaProducer(cfg KafkaProducerConfig) *KafkaProducer {
+	if cfg.MaxInFlight <= 0 {
+		cfg.MaxInFlight = DefaultKafkaProducerConfig(nil).MaxInFlight
+	}
+	transport := cfg.Transport
+	if transport == nil {
+		transport = &kafka.Transport{}
+	}
+	p := &KafkaProducer{
+		config:    cfg,
+		transport: transport,
+		slots:     make(chan struct{}, cfg.MaxInFlight),
+		writers:   make(map[string]*kafka.Writer),
+	}
+	p.idle = sync.NewCond(&p.mu)
+	return p
+}
+
+// ✅ acquire returns the cached writer for a topic, creating it on first use, and
+// registers a pending message that Flush and Close wait for; callers must call release
+func (p *KafkaProducer) acquire(topic string) (*kafka.Writer, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		return nil, ErrProducerClosed
+	}
+	w, ok := p.writers[topic]
+	if !ok {
+		w = &kafka.Writer{
+			Addr:         kafka.TCP(p.config.Brokers...),
+			Topic:        topic,
+			Balancer:     &kafka.Murmur2Balancer{}, // ✅ Same key → same partition, matching the Java client
+			BatchSize:    p.config.BatchSize,
+			BatchBytes:   p.config.BatchBytes,
+			BatchTimeout: p.config.Linger,
+			Compression:  p.config.Compression,
+			WriteTimeout: p.config.WriteTimeout,
+			RequiredAcks: kafka.RequireAll,
+			Async:        true,
+			Completion:   p.completed,
+			Transport:    p.transport,
+		}
+		p.writers[topic] = w
+	}
+	p.pending++
+	return w, nil
+}
+
+func (p *KafkaProducer) release() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.pending--
+	if p.pending == 0 {
+		p.idle.Broadcast()
+	}
+}
+
+// waitIdle blocks until no message is pending
+func (p *KafkaProducer) waitIdle() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for p.pending > 0 {
+		p.idle.Wait()
 	}
 }
 
-// ✅ Send Message to Dynamic Topics
+// ✅ Produce: Sends a message and waits for the broker
+// It is queued behind earlier messages with the same key, like ProduceAsync.
+func (p *KafkaProducer) Produce(msg Message) error {
+	report := make(chan DeliveryReport, 1)
+	p.ProduceAsync(msg, func(r DeliveryReport) { report <- r })
+	return (<-report).Err
+}
+
+// ✅ ProduceMessage: Produce for callers without headers
 func (p *KafkaProducer) ProduceMessage(topic string, key string, value string) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
+	return p.Produce(Message{Topic: topic, Key: key, Value: []byte(value)})
+}
 
-	// ✅ Create a new writer for each topic dynamically
-	writer := kafka.NewWriter(kafka.WriterConfig{
-		Brokers:   p.Brokers,
-		Topic:     topic, // ✅ Dynamic Topic
-		Balancer:  &kafka.LeastBytes{},
-		BatchSize: 1,
-	})
+// ✅ ProduceAsync: Queues the message and reports the broker's answer to onDelivery
+// Messages are queued in call order, so one caller's messages with the same key
+// are written in order. Blocks only when MaxInFlight messages are already
+// awaiting delivery. onDelivery runs on a producer goroutine and must not block
+// for long.
+func (p *KafkaProducer) ProduceAsync(msg Message, onDelivery func(DeliveryReport)) {
+	w, err := p.acquire(msg.Topic)
+	if err != nil {
+		onDelivery(DeliveryReport{Message: msg, Err: err})
+		return
+	}
+	p.slots <- struct{}{}
 
 	message := kafka.Message{
-		Key:   []byte(key),
-		Value: []byte(value),
+		Value:      msg.Value,
+		Headers:    msg.Headers.Kafka(),
+		WriterData: &delivery{msg: msg, onDelivery: onDelivery},
+	}
+	if msg.Key != "" {
+		message.Key = []byte(msg.Key) // ✅ A nil key is spread across partitions, an empty one is not
 	}
 
-	err := writer.WriteMessages(ctx, message)
+	// ✅ In async mode this only looks up partitions and queues the message
+	ctx, cancel := context.WithTimeout(context.Background(), p.config.WriteTimeout)
+	defer cancel()
+	if err := w.WriteMessages(ctx, message); err != nil {
+		p.report(message.WriterData.(*delivery), err)
+	}
+}
+
+// completed: The writer's Completion callback, once per written batch
+func (p *KafkaProducer) completed(messages []kafka.Message, err error) {
+	for _, message := range messages {
+		if d, ok := message.WriterData.(*delivery); ok {
+			p.report(d, err)
+		}
+	}
+}
+
+// report logs the outcome, frees the message's slot and calls its onDelivery
+func (p *KafkaProducer) report(d *delivery, err error) {
 	if err != nil {
 		middleware.Log.WithFields(logrus.Fields{
-			"error": err,
-			"topic": topic,
+			"error":    err,
+			"topic":    d.msg.Topic,
+			"event_id": d.msg.Headers.Get(HeaderEventID),
 		}).Error("❌ Error producing Kafka message")
-		return err
+	} else {
+		middleware.Log.WithFields(logrus.Fields{
+			"message":  "✅ Kafka Message Sent",
+			"topic":    d.msg.Topic,
+			"event_id": d.msg.Headers.Get(HeaderEventID),
+			"value":    string(d.msg.Value),
+		}).Info("✅ Message sent successfully to Kafka")
+	}
+
+	<-p.slots
+	d.onDelivery(DeliveryReport{Message: d.msg, Err: err})
+	p.release()
+}
+
+// ✅ Flush: Waits until every in-flight message has been acknowledged or failed
+func (p *KafkaProducer) Flush(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		p.waitIdle()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// ✅ Close: Stops accepting messages, flushes pending batches and closes every writer
+func (p *KafkaProducer) Close() error {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return nil
 	}
+	p.closed = true
+	p.mu.Unlock()
 
-	middleware.Log.WithFields(logrus.Fields{
-		"message": "✅ Kafka Message Sent",
-		"topic":   topic,
-		"value":   value,
-	}).Info("✅ Message sent successfully to Kafka")
+	p.waitIdle()
 
-	// ✅ Close writer after sending the message
-	return writer.Close()
+	// ✅ No writer is created once closed is set, so the map is stable here
+	var errs []error
+	for topic, w := range p.writers {
+		if err := w.Close(); err != nil {
+			errs = append(errs, err)
+			middleware.Log.WithFields(logrus.Fields{"error": err, "topic": topic}).Error("❌ Error closing Kafka writer")
+		}
+	}
+	if transport, ok := p.transport.(*kafka.Transport); ok {
+		transport.CloseIdleConnections()
+	}
+	middleware.Log.Info("Kafka producer closed")
+	return errors.Join(errs...)
 }
@@ -0,0 +1,208 @@
+package kafkaadmin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// ✅ Admin - Reads and changes cluster state through the Kafka admin APIs
+type Admin struct {
+	Client *kafka.Client
+}
+
+func NewAdmin(brokers ...string) *Admin {
+	return &Admin{Client: &kafka.Client{Addr: kafka.TCP(brokers...), Timeout: 10 * time.Second}}
+}
+
+// ✅ Ping succeeds once a broker answers a metadata request
+func (a *Admin) Ping(ctx context.Context) error {
+	_, err := a.Client.Metadata(ctx, &kafka.MetadataRequest{})
+	return err
+}
+
+// ✅ WaitUntilReady pings every interval until a broker answers or ctx ends
+func (a *Admin) WaitUntilReady(ctx context.Context, interval time.Duration) error {
+	for {
+		err := a.Ping(ctx)
+		if err == nil {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("kafka not ready: %w", err)
+		case <-time.After(interval):
+		}
+	}
+}
+
+// ✅ Topics: The current state of the named topics that exist, keyed by name
+// All topics are listed rather than asked for by name, so brokers with
+// auto.create.topics.enable do not create the missing ones.
+func (a *Admin) Topics(ctx context.Context, names []string) (map[string]TopicState, error) {
+	metadata, err := a.Client.Metadata(ctx, &kafka.MetadataRequest{})
+	if err != nil {
+		return nil, err
+	}
+	wanted := make(map[string]bool, len(names))
+	for _, name := range names {
+		wanted[name] = true
+	}
+
+	states := make(map[string]TopicState)
+	var resources []kafka.DescribeConfigRequestResource
+	for _, topic := range metadata.Topics {
+		if !wanted[topic.Name] {
+			continue
+		}
+		if topic.Error != nil {
+			return nil, fmt.Errorf("describe topic %s: %w", topic.Name, topic.Error)
+		}
+		state := TopicState{Name: topic.Name, Partitions: len(topic.Partitions), Configs: make(map[string]string)}
+		if len(topic.Partitions) > 0 {
+			state.ReplicationFactor = len(topic.Partitions[0].Replicas)
+		}
+		states[topic.Name] = state
+		resources = append(resources, kafka.DescribeConfigRequestResource{
+			ResourceType: kafka.ResourceTypeTopic,
+			ResourceName: topic.Name,
+			ConfigNames:  ManagedConfigs,
+		})
+	}
+	if len(resources) == 0 {
+		return states, nil
+	}
+
+	configs, err := a.Client.DescribeConfigs(ctx, &kafka.DescribeConfigsRequest{Resources: resources})
+	if err != nil {
+		return nil, err
+	}
+	for _, resource := range configs.Resources {
+		if resource.Error != nil {
+			return nil, fmt.Errorf("describe configs of %s: %w", resource.ResourceName, resource.Error)
+		}
+		for _, entry := range resource.ConfigEntries {
+			states[resource.ResourceName].Configs[entry.ConfigName] = entry.ConfigValue
+		}
+	}
+	return states, nil
+}
+
+// ✅ Plan compares the spec with the cluster
+func (a *Admin) Plan(ctx context.Context, spec Spec) (Plan, error) {
+	names := make([]string, 0, len(spec.Topics))
+	for _, topic := range spec.Topics {
+		names = append(names, topic.Name)
+	}
+	current, err := a.Topics(ctx, names)
+	if err != nil {
+		return Plan{}, err
+	}
+	return NewPlan(spec, current), nil
+}
+
+// ✅ Apply makes every supported change in the plan
+// Changes that fail, and unsupported ones, are reported together in the error.
+func (a *Admin) Apply(ctx context.Context, plan Plan) error {
+	var creates []kafka.TopicConfig
+	var partitions []kafka.TopicPartitionsConfig
+	alters := make(map[string][]kafka.IncrementalAlterConfigsRequestConfig)
+	var alterOrder []string
+	var errs []error
+
+	for _, change := range plan.Changes {
+		switch change.Action {
+		case ActionCreate:
+			creates = append(creates, topicConfig(change.Topic))
+		case ActionAddPartitions:
+			partitions = append(partitions, kafka.TopicPartitionsConfig{
+				Name:  change.Topic.Name,
+				Count: int32(change.Topic.Partitions),
+			})
+		case ActionAlterConfig:
+			if _, ok := alters[change.Topic.Name]; !ok {
+				alterOrder = append(alterOrder, change.Topic.Name)
+			}
+			alters[change.Topic.Name] = append(alters[change.Topic.Name], kafka.IncrementalAlterConfigsRequestConfig{
+				Name:            change.Field,
+				Value:           change.To,
+				ConfigOperation: kafka.ConfigOperationSet,
+			})
+		default:
+			errs = append(errs, fmt.Errorf("%s: %s cannot be changed from %s to %s by kafka-admin", change.Topic.Name, change.Field, change.From, change.To))
+		}
+	}
+
+	if len(creates) > 0 {
+		res, err := a.Client.CreateTopics(ctx, &kafka.CreateTopicsRequest{Topics: creates})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("create topics: %w", err))
+		} else {
+			errs = append(errs, topicErrors("create", res.Errors)...)
+		}
+	}
+	if len(partitions) > 0 {
+		res, err := a.Client.CreatePartitions(ctx, &kafka.CreatePartitionsRequest{Topics: partitions})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("add partitions: %w", err))
+		} else {
+			errs = append(errs, topicErrors("add partitions to", res.Errors)...)
+		}
+	}
+	if len(alters) > 0 {
+		req := &kafka.IncrementalAlterConfigsRequest{}
+		for _, name := range alterOrder {
+			req.Resources = append(req.Resources, kafka.IncrementalAlterConfigsRequestResource{
+				ResourceType: kafka.ResourceTypeTopic,
+				ResourceName: name,
+				Configs:      alters[name],
+			})
+		}
+		res, err := a.Client.IncrementalAlterConfigs(ctx, req)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("alter configs: %w", err))
+		} else {
+			for _, resource := range res.Resources {
+				if resource.Error != nil {
+					errs = append(errs, fmt.Errorf("alter configs of %s: %w", resource.ResourceName, resource.Error))
+				}
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// topicConfig: The create request for a topic, with its managed configs
+func topicConfig(topic TopicSpec) kafka.TopicConfig {
+	config := kafka.TopicConfig{
+		Topic:             topic.Name,
+		NumPartitions:     topic.Partitions,
+		ReplicationFactor: topic.ReplicationFactor,
+	}
+	for _, name := range ManagedConfigs {
+		if value, ok := topic.Configs()[name]; ok {
+			config.ConfigEntries = append(config.ConfigEntries, kafka.ConfigEntry{ConfigName: name, ConfigValue: value})
+		}
+	}
+	return config
+}
+
+// topicErrors: The per-topic failures of a CreateTopics or CreatePartitions response, by topic name
+func topicErrors(verb string, byTopic map[string]error) []error {
+	names := make([]string, 0, len(byTopic))
+	for name, err := range byTopic {
+		if err != nil {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	errs := make([]error, 0, len(names))
+	for _, name := range names {
+		errs = append(errs, fmt.Errorf("%s %s: %w", verb, name, byTopic[name]))
+	}
+	return errs
+}
@@ -0,0 +1,402 @@
+package kafkaadmin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// ✅ Group - A consumer group as its coordinator sees it
+type Group struct {
+	ID      string
+	State   string // Stable, Empty, PreparingRebalance, CompletingRebalance or Dead
+	Members []Member
+}
+
+// ✅ Member - One consumer in a group and the partitions it owns
+type Member struct {
+	ID          string
+	ClientID    string
+	Host        string
+	Assignments map[string][]int // Topic → partitions
+}
+
+// ✅ Active is true while consumers are connected; offsets cannot be reset then
+func (g Group) Active() bool {
+	return len(g.Members) > 0
+}
+
+// ✅ PartitionOffsets - A partition's range and where a group is in it
+type PartitionOffsets struct {
+	Topic     string
+	Partition int
+	Start     int64  // Earliest offset still on the broker
+	End       int64  // Offset the next message will get
+	Committed int64  // -1 when the group has not committed on the partition
+	Member    string // Member that owns the partition; empty when unassigned
+}
+
+// ✅ Lag: Messages the group has yet to handle; uncommitted partitions count from Start
+func (p PartitionOffsets) Lag() int64 {
+	if p.Committed < 0 {
+		return p.End - p.Start
+	}
+	return max(p.End-p.Committed, 0)
+}
+
+// ✅ Where an offset reset moves a group
+const (
+	ResetEarliest  = "earliest"
+	ResetLatest    = "latest"
+	ResetTimestamp = "timestamp" // First message at or after Time; the end if there is none
+	ResetOffset    = "offset"    // Offset on every partition, clamped to [Start, End]
+)
+
+// ✅ ResetTarget - Where to move a group's offsets
+type ResetTarget struct {
+	Kind   string
+	Time   time.Time // ResetTimestamp
+	Offset int64     // ResetOffset
+}
+
+// ✅ Resolve: The offset the target means for a partition; ResetTimestamp is resolved by the broker instead
+func (t ResetTarget) Resolve(p PartitionOffsets) int64 {
+	switch t.Kind {
+	case ResetEarliest:
+		return p.Start
+	case ResetLatest:
+		return p.End
+	default:
+		return min(max(t.Offset, p.Start), p.End)
+	}
+}
+
+// ✅ OffsetChange - One partition's committed offset before and after a reset
+type OffsetChange struct {
+	Topic     string
+	Partition int
+	From      int64 // -1 when the group had not committed
+	To        int64
+}
+
+// ✅ Groups: Every consumer group on the cluster, sorted by ID
+func (a *Admin) Groups(ctx context.Context) ([]Group, error) {
+	listed, err := a.Client.ListGroups(ctx, &kafka.ListGroupsRequest{})
+	if err != nil {
+		return nil, err
+	}
+	if listed.Error != nil {
+		return nil, fmt.Errorf("list groups: %w", listed.Error)
+	}
+	if len(listed.Groups) == 0 {
+		return nil, nil
+	}
+	ids := make([]string, 0, len(listed.Groups))
+	for _, group := range listed.Groups {
+		ids = append(ids, group.GroupID)
+	}
+	groups, err := a.describeGroups(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i].ID < groups[j].ID })
+	return groups, nil
+}
+
+// ✅ DescribeGroup: State, members and their assignments
+func (a *Admin) DescribeGroup(ctx context.Context, id string) (Group, error) {
+	groups, err := a.describeGroups(ctx, []string{id})
+	if err != nil {
+		return Group{}, err
+	}
+	// ⚠️ Coordinators report unknown groups as Dead rather than as an error
+	if len(groups) == 0 || groups[0].State == "Dead" {
+		return Group{}, fmt.Errorf("group %s not found", id)
+	}
+	return groups[0], nil
+}
+
+func (a *Admin) describeGroups(ctx context.Context, ids []string) ([]Group, error) {
+	res, err := a.Client.DescribeGroups(ctx, &kafka.DescribeGroupsRequest{GroupIDs: ids})
+	if err != nil {
+		return nil, err
+	}
+	groups := make([]Group, 0, len(res.Groups))
+	for _, described := range res.Groups {
+		if described.Error != nil {
+			return nil, fmt.Errorf("describe group %s: %w", described.GroupID, described.Error)
+		}
+		group := Group{ID: described.GroupID, State: described.GroupState}
+		for _, member := range described.Members {
+			assignments := make(map[string][]int)
+			for _, topic := range member.MemberAssignments.Topics {
+				assignments[topic.Topic] = append(assignments[topic.Topic], topic.Partitions...)
+			}
+			group.Members = append(group.Members, Member{
+				ID:          member.MemberID,
+				ClientID:    member.ClientID,
+				Host:        member.ClientHost,
+				Assignments: assignments,
+			})
+		}
+		sort.Slice(group.Members, func(i, j int) bool { return group.Members[i].ID < group.Members[j].ID })
+		groups = append(groups, group)
+	}
+	return groups, nil
+}
+
+// ✅ Lag: Offsets of every partition of every topic the group has committed on or is assigned
+func (a *Admin) Lag(ctx context.Context, id string) ([]PartitionOffsets, error) {
+	group, err := a.DescribeGroup(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return a.groupOffsets(ctx, group, nil)
+}
+
+// ✅ PlanReset: What resetting the group's offsets on topics would change; nil topics means
+// every topic the group has committed on. Nothing is written.
+func (a *Admin) PlanReset(ctx context.Context, id string, topics []string, target ResetTarget) ([]OffsetChange, error) {
+	group, err := a.DescribeGroup(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	offsets, err := a.groupOffsets(ctx, group, topics)
+	if err != nil {
+		return nil, err
+	}
+
+	var byTime map[string]map[int]int64
+	if target.Kind == ResetTimestamp {
+		if byTime, err = a.listOffsets(ctx, partitionsOf(offsets), target.Time.UnixMilli()); err != nil {
+			return nil, err
+		}
+	}
+
+	changes := make([]OffsetChange, 0, len(offsets))
+	for _, partition := range offsets {
+		to := target.Resolve(partition)
+		if target.Kind == ResetTimestamp {
+			// ✅ -1 means no message at or after the time
+			to = partition.End
+			if found, ok := byTime[partition.Topic][partition.Partition]; ok && found >= 0 {
+				to = found
+			}
+		}
+		changes = append(changes, OffsetChange{Topic: partition.Topic, Partition: partition.Partition, From: partition.Committed, To: to})
+	}
+	return changes, nil
+}
+
+// ✅ ApplyReset commits the planned offsets for the group
+// The group must have no active members, or they would overwrite the reset.
+func (a *Admin) ApplyReset(ctx context.Context, id string, changes []OffsetChange) error {
+	group, err := a.DescribeGroup(ctx, id)
+	if err != nil {
+		return err
+	}
+	if group.Active() {
+		return fmt.Errorf("group %s has %d active member(s); stop its consumers before resetting offsets", id, len(group.Members))
+	}
+	if len(changes) == 0 {
+		return nil
+	}
+
+	commits := make(map[string][]kafka.OffsetCommit)
+	for _, change := range changes {
+		commits[change.Topic] = append(commits[change.Topic], kafka.OffsetCommit{Partition: change.Partition, Offset: change.To})
+	}
+	res, err := a.Client.OffsetCommit(ctx, &kafka.OffsetCommitRequest{GroupID: id, GenerationID: -1, Topics: commits})
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for topic, partitions := range res.Topics {
+		for _, partition := range partitions {
+			if partition.Error != nil {
+				errs = append(errs, fmt.Errorf("commit %s[%d]: %w", topic, partition.Partition, partition.Error))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// groupOffsets: Start, end and committed offsets of every partition of topics, or of the
+// group's topics when topics is nil, sorted by topic and partition
+func (a *Admin) groupOffsets(ctx context.Context, group Group, topics []string) ([]PartitionOffsets, error) {
+	committed, err := a.Client.OffsetFetch(ctx, &kafka.OffsetFetchRequest{GroupID: group.ID})
+	if err != nil {
+		return nil, err
+	}
+	if committed.Error != nil {
+		return nil, fmt.Errorf("fetch offsets of %s: %w", group.ID, committed.Error)
+	}
+
+	if topics == nil {
+		seen := make(map[string]bool)
+		for topic := range committed.Topics {
+			seen[topic] = true
+		}
+		for _, member := range group.Members {
+			for topic := range member.Assignments {
+				seen[topic] = true
+			}
+		}
+		for topic := range seen {
+			topics = append(topics, topic)
+		}
+	}
+	owners := make(map[string]map[int]string)
+	for _, member := range group.Members {
+		for topic, partitions := range member.Assignments {
+			if owners[topic] == nil {
+				owners[topic] = make(map[int]string)
+			}
+			for _, partition := range partitions {
+				owners[topic][partition] = member.ID
+			}
+		}
+	}
+	if len(topics) == 0 {
+		return nil, nil
+	}
+
+	partitions, err := a.partitions(ctx, topics)
+	if err != nil {
+		return nil, err
+	}
+	starts, err := a.listOffsets(ctx, partitions, kafka.FirstOffset)
+	if err != nil {
+		return nil, err
+	}
+	ends, err := a.listOffsets(ctx, partitions, kafka.LastOffset)
+	if err != nil {
+		return nil, err
+	}
+	commits := make(map[string]map[int]int64)
+	for topic, fetched := range committed.Topics {
+		commits[topic] = make(map[int]int64)
+		for _, partition := range fetched {
+			if partition.Error != nil {
+				return nil, fmt.Errorf("fetch offset of %s[%d]: %w", topic, partition.Partition, partition.Error)
+			}
+			commits[topic][partition.Partition] = partition.CommittedOffset
+		}
+	}
+
+	var offsets []PartitionOffsets
+	for topic, ids := range partitions {
+		for _, partition := range ids {
+			current, ok := commits[topic][partition]
+			if !ok {
+				current = -1
+			}
+			offsets = append(offsets, PartitionOffsets{
+				Topic:     topic,
+				Partition: partition,
+				Start:     starts[topic][partition],
+				End:       ends[topic][partition],
+				Committed: current,
+				Member:    owners[topic][partition],
+			})
+		}
+	}
+	sort.Slice(offsets, func(i, j int) bool {
+		if offsets[i].Topic != offsets[j].Topic {
+			return offsets[i].Topic < offsets[j].Topic
+		}
+		return offsets[i].Partition < offsets[j].Partition
+	})
+	return offsets, nil
+}
+
+// partitions: Partition IDs of each topic; a topic that does not exist is an error
+func (a *Admin) partitions(ctx context.Context, topics []string) (map[string][]int, error) {
+	metadata, err := a.Client.Metadata(ctx, &kafka.MetadataRequest{})
+	if err != nil {
+		return nil, err
+	}
+	existing := make(map[string][]int)
+	for _, topic := range metadata.Topics {
+		for _, partition := range topic.Partitions {
+			existing[topic.Name] = append(existing[topic.Name], partition.ID)
+		}
+	}
+	partitions := make(map[string][]int, len(topics))
+	for _, topic := range topics {
+		ids, ok := existing[topic]
+		if !ok {
+			return nil, fmt.Errorf("topic %s not found", topic)
+		}
+		partitions[topic] = ids
+	}
+	return partitions, nil
+}
+
+// listOffsets: The offset at timestamp for every partition; kafka.FirstOffset and
+// kafka.LastOffset ask for the start and the end. One timestamp per call, since
+// a request may name each partition only once.
+func (a *Admin) listOffsets(ctx context.Context, partitions map[string][]int, timestamp int64) (map[string]map[int]int64, error) {
+	req := &kafka.ListOffsetsRequest{Topics: make(map[string][]kafka.OffsetRequest)}
+	for topic, ids := range partitions {
+		for _, id := range ids {
+			req.Topics[topic] = append(req.Topics[topic], kafka.OffsetRequest{Partition: id, Timestamp: timestamp})
+		}
+	}
+	res, err := a.Client.ListOffsets(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	offsets := make(map[string]map[int]int64)
+	for topic, listed := range res.Topics {
+		offsets[topic] = make(map[int]int64)
+		for _, partition := range listed {
+			if partition.Error != nil {
+				return nil, fmt.Errorf("list offsets of %s[%d]: %w", topic, partition.Partition, partition.Error)
+			}
+			offsets[topic][partition.Partition] = answeredOffset(partition, timestamp)
+		}
+	}
+	return offsets, nil
+}
+
+// answeredOffset: The one offset the broker returned for a partition
+// kafka-go files each answer by the timestamp in the response rather than the one
+// asked for, and brokers answer both earliest and latest with timestamp -1, so the
+// start of a partition lands in LastOffset. FirstOffset and LastOffset start at 0
+// when asked for and -1 otherwise; a field that moved from there holds the answer.
+func answeredOffset(partition kafka.PartitionOffsets, timestamp int64) int64 {
+	for offset := range partition.Offsets {
+		return offset
+	}
+	askedFirst, askedLast := int64(-1), int64(-1)
+	switch timestamp {
+	case kafka.FirstOffset:
+		askedFirst = 0
+	case kafka.LastOffset:
+		askedLast = 0
+	}
+	switch {
+	case partition.LastOffset != askedLast:
+		return partition.LastOffset
+	case partition.FirstOffset != askedFirst:
+		return partition.FirstOffset
+	case timestamp == kafka.FirstOffset:
+		return partition.FirstOffset
+	default:
+		return partition.LastOffset
+	}
+}
+
+func partitionsOf(offsets []PartitionOffsets) map[string][]int {
+	partitions := make(map[string][]int)
+	for _, partition := range offsets {
+		partitions[partition.Topic] = append(partitions[partition.Topic], partition.Partition)
+	}
+	return partitions
+}
@@ -0,0 +1,150 @@
+package kafkaadmin
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ✅ TopicState - What the cluster currently has for a topic
+type TopicState struct {
+	Name              string
+	Partitions        int
+	ReplicationFactor int
+	Configs           map[string]string // Managed configs only
+}
+
+// ✅ Kinds of change in a plan
+const (
+	ActionCreate        = "create"
+	ActionAddPartitions = "add-partitions"
+	ActionAlterConfig   = "alter-config"
+	ActionUnsupported   = "unsupported" // Drift kafka-admin will not fix, e.g. fewer partitions
+)
+
+// ✅ Change - One difference between the spec and the cluster
+type Change struct {
+	Action string
+	Topic  TopicSpec
+	Field  string // "partitions", "replication_factor" or a config name; empty for creates
+	From   string
+	To     string
+}
+
+func (c Change) String() string {
+	switch c.Action {
+	case ActionCreate:
+		return fmt.Sprintf("+ %s %s", c.Topic.Name, c.To)
+	case ActionUnsupported:
+		return fmt.Sprintf("! %s %s: %s → %s (change it by hand)", c.Topic.Name, c.Field, c.From, c.To)
+	default:
+		return fmt.Sprintf("~ %s %s: %s → %s", c.Topic.Name, c.Field, c.From, c.To)
+	}
+}
+
+// ✅ Plan - The changes that bring the cluster in line with a spec, in spec order
+type Plan struct {
+	Changes []Change
+}
+
+// ✅ Drift is true when the cluster does not match the spec
+func (p Plan) Drift() bool {
+	return len(p.Changes) > 0
+}
+
+// ✅ Unsupported: The changes Apply will not make
+func (p Plan) Unsupported() []Change {
+	var unsupported []Change
+	for _, change := range p.Changes {
+		if change.Action == ActionUnsupported {
+			unsupported = append(unsupported, change)
+		}
+	}
+	return unsupported
+}
+
+// ✅ String is the dry-run diff, one change per line
+func (p Plan) String() string {
+	if !p.Drift() {
+		return "No changes; the cluster matches the spec.\n"
+	}
+	var b strings.Builder
+	for _, change := range p.Changes {
+		b.WriteString(change.String())
+		b.WriteByte('\n')
+	}
+	fmt.Fprintf(&b, "%d change(s)", len(p.Changes))
+	if unsupported := len(p.Unsupported()); unsupported > 0 {
+		fmt.Fprintf(&b, ", %d not applied automatically", unsupported)
+	}
+	b.WriteByte('\n')
+	return b.String()
+}
+
+// ✅ NewPlan compares the spec with the cluster's topics, keyed by name
+func NewPlan(spec Spec, current map[string]TopicState) Plan {
+	var plan Plan
+	for _, topic := range spec.Topics {
+		state, exists := current[topic.Name]
+		if !exists {
+			plan.Changes = append(plan.Changes, Change{Action: ActionCreate, Topic: topic, To: describe(topic)})
+			continue
+		}
+
+		switch {
+		case topic.Partitions > state.Partitions:
+			plan.Changes = append(plan.Changes, Change{
+				Action: ActionAddPartitions, Topic: topic, Field: "partitions",
+				From: strconv.Itoa(state.Partitions), To: strconv.Itoa(topic.Partitions),
+			})
+		case topic.Partitions < state.Partitions:
+			// ⚠️ Kafka cannot remove partitions
+			plan.Changes = append(plan.Changes, Change{
+				Action: ActionUnsupported, Topic: topic, Field: "partitions",
+				From: strconv.Itoa(state.Partitions), To: strconv.Itoa(topic.Partitions),
+			})
+		}
+		if topic.ReplicationFactor != state.ReplicationFactor {
+			// ⚠️ Needs a partition reassignment, which is out of scope here
+			plan.Changes = append(plan.Changes, Change{
+				Action: ActionUnsupported, Topic: topic, Field: "replication_factor",
+				From: strconv.Itoa(state.ReplicationFactor), To: strconv.Itoa(topic.ReplicationFactor),
+			})
+		}
+
+		desired := topic.Configs()
+		for _, name := range ManagedConfigs {
+			want, managed := desired[name]
+			if !managed || state.Configs[name] == want {
+				continue
+			}
+			from := state.Configs[name]
+			if from == "" {
+				from = "(unset)"
+			}
+			plan.Changes = append(plan.Changes, Change{
+				Action: ActionAlterConfig, Topic: topic, Field: name, From: from, To: want,
+			})
+		}
+	}
+	return plan
+}
+
+// describe: e.g. "partitions=3 replication_factor=1 cleanup.policy=delete retention.ms=604800000"
+func describe(topic TopicSpec) string {
+	parts := []string{
+		"partitions=" + strconv.Itoa(topic.Partitions),
+		"replication_factor=" + strconv.Itoa(topic.ReplicationFactor),
+	}
+	configs := topic.Configs()
+	names := make([]string, 0, len(configs))
+	for name := range configs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		parts = append(parts, name+"="+configs[name])
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,136 @@
+package kafkaadmin
+
+import (
+	"GoSyntaxDoc/domain/topics"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ✅ Topic configs kafka-admin manages; every other config is left alone
+const (
+	ConfigRetention         = "retention.ms"
+	ConfigCleanupPolicy     = "cleanup.policy"
+	ConfigMinInSyncReplicas = "min.insync.replicas"
+)
+
+// ManagedConfigs lists the configs compared against the cluster, in plan order
+var ManagedConfigs = []string{ConfigRetention, ConfigCleanupPolicy, ConfigMinInSyncReplicas}
+
+// ✅ Spec - The topics a cluster should have
+// Topics on the cluster that are not in the spec are never touched.
+type Spec struct {
+	Topics []TopicSpec `yaml:"topics"`
+}
+
+// ✅ TopicSpec - One topic; zero config values keep whatever the cluster has
+type TopicSpec struct {
+	Name              string        `yaml:"name"`
+	Partitions        int           `yaml:"partitions"`
+	ReplicationFactor int           `yaml:"replication_factor"`
+	Retention         time.Duration `yaml:"retention,omitempty"`      // e.g. "168h"; a negative value such as "-1s" keeps messages forever
+	CleanupPolicy     string        `yaml:"cleanup_policy,omitempty"` // delete, compact or "compact,delete"
+	MinInSyncReplicas int           `yaml:"min_insync_replicas,omitempty"`
+}
+
+// ✅ Configs: The managed configs the spec sets, as Kafka config entries
+func (t TopicSpec) Configs() map[string]string {
+	configs := make(map[string]string)
+	if t.Retention != 0 {
+		configs[ConfigRetention] = strconv.FormatInt(retentionMs(t.Retention), 10)
+	}
+	if t.CleanupPolicy != "" {
+		configs[ConfigCleanupPolicy] = t.CleanupPolicy
+	}
+	if t.MinInSyncReplicas != 0 {
+		configs[ConfigMinInSyncReplicas] = strconv.Itoa(t.MinInSyncReplicas)
+	}
+	return configs
+}
+
+// retentionMs: Negative retentions mean "forever", which Kafka spells -1
+func retentionMs(retention time.Duration) int64 {
+	if retention < 0 {
+		return -1
+	}
+	return retention.Milliseconds()
+}
+
+// ✅ Validate reports every problem in the spec at once
+func (s Spec) Validate() error {
+	var errs []error
+	seen := make(map[string]bool)
+	for i, topic := range s.Topics {
+		if topic.Name == "" {
+			errs = append(errs, fmt.Errorf("topics[%d]: name is required", i))
+			continue
+		}
+		if seen[topic.Name] {
+			errs = append(errs, fmt.Errorf("%s: declared more than once", topic.Name))
+		}
+		seen[topic.Name] = true
+		if topic.Partitions < 1 {
+			errs = append(errs, fmt.Errorf("%s: partitions must be at least 1", topic.Name))
+		}
+		if topic.ReplicationFactor < 1 {
+			errs = append(errs, fmt.Errorf("%s: replication_factor must be at least 1", topic.Name))
+		}
+		switch topic.CleanupPolicy {
+		case "", "delete", "compact", "compact,delete", "delete,compact":
+		default:
+			errs = append(errs, fmt.Errorf("%s: unknown cleanup_policy %q", topic.Name, topic.CleanupPolicy))
+		}
+		if topic.MinInSyncReplicas < 0 || topic.MinInSyncReplicas > topic.ReplicationFactor {
+			errs = append(errs, fmt.Errorf("%s: min_insync_replicas must be between 1 and replication_factor", topic.Name))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// ✅ ParseSpec reads a YAML spec; JSON is valid YAML, so JSON specs work too
+func ParseSpec(data []byte) (Spec, error) {
+	var spec Spec
+	if err := yaml.Unmarshal(data, &spec); err != nil {
+		return Spec{}, fmt.Errorf("parse topic spec: %w", err)
+	}
+	if err := spec.Validate(); err != nil {
+		return Spec{}, fmt.Errorf("invalid topic spec: %w", err)
+	}
+	return spec, nil
+}
+
+// ✅ LoadSpec reads the spec at path, or the topic catalog when path is empty
+func LoadSpec(path string) (Spec, error) {
+	if path == "" {
+		return SpecFromCatalog(topics.All()), nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Spec{}, fmt.Errorf("read topic spec: %w", err)
+	}
+	return ParseSpec(data)
+}
+
+// ✅ SpecFromCatalog: The spec the services expect, from domain/topics
+func SpecFromCatalog(catalog []topics.Topic) Spec {
+	spec := Spec{Topics: make([]TopicSpec, 0, len(catalog))}
+	for _, topic := range catalog {
+		spec.Topics = append(spec.Topics, TopicSpec{
+			Name:              topic.Name,
+			Partitions:        topic.Partitions,
+			ReplicationFactor: topic.ReplicationFactor,
+			Retention:         topic.Retention,
+			CleanupPolicy:     topic.CleanupPolicy,
+		})
+	}
+	return spec
+}
+
+// ✅ Marshal writes the spec as YAML, e.g. to start a spec file from the catalog
+func (s Spec) Marshal() ([]byte, error) {
+	return yaml.Marshal(s)
+}
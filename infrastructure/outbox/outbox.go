@@ -0,0 +1,68 @@
+package outbox
+
+import (
+	"GoSyntaxDoc/domain/events"
+	"GoSyntaxDoc/infrastructure"
+	"context"
+	"encoding/json"
+	"strconv"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// ✅ Message - A row of the outbox table
+type Message struct {
+	ID       int64
+	Topic    string
+	Key      string
+	Payload  []byte // The JSON envelope
+	Headers  infrastructure.Headers
+	Attempts int
+
+	KafkaSent bool // Already produced; only the Redis fan-out is left
+}
+
+// ✅ originHeaders are copied from the command that caused the event, so the
+// relay can route the reply and traces continue across the hop
+var originHeaders = []string{
+	infrastructure.HeaderCorrelationID,
+	infrastructure.HeaderRequestID,
+	infrastructure.HeaderConnectionID,
+	infrastructure.HeaderUserSubject,
+	infrastructure.HeaderUserTenant,
+	infrastructure.HeaderTraceparent,
+}
+
+// ✅ NewMessage: Wraps an envelope for the outbox; origin are the headers of the causing command, if any
+func NewMessage(topic string, key string, envelope events.Envelope, origin infrastructure.Headers) (Message, error) {
+	payload, err := json.Marshal(envelope)
+	if err != nil {
+		return Message{}, err
+	}
+
+	headers := infrastructure.Headers{
+		infrastructure.HeaderEventID:       envelope.EventID,
+		infrastructure.HeaderSchemaVersion: strconv.Itoa(envelope.SchemaVersion),
+		infrastructure.HeaderContentType:   infrastructure.ContentTypeJSON,
+	}
+	for _, name := range originHeaders {
+		if value := origin.Get(name); value != "" {
+			headers[name] = value
+		}
+	}
+
+	return Message{Topic: topic, Key: key, Payload: payload, Headers: headers}, nil
+}
+
+// ✅ Insert: Adds the message within tx, so it is only visible if the surrounding change commits
+func Insert(ctx context.Context, tx pgx.Tx, msg Message) error {
+	headers := msg.Headers
+	if headers == nil {
+		headers = infrastructure.Headers{}
+	}
+	_, err := tx.Exec(ctx,
+		`INSERT INTO outbox (topic, message_key, payload, headers) VALUES ($1, $2, $3, $4)`,
+		msg.Topic, msg.Key, msg.Payload, headers,
+	)
+	return err
+}
@@ -0,0 +1,226 @@
+package outbox
+
+import (
+	"GoSyntaxDoc/config"
+	"GoSyntaxDoc/domain/events"
+	"GoSyntaxDoc/infrastructure"
+	"GoSyntaxDoc/infrastructure/redis"
+	"GoSyntaxDoc/presentation/middleware"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ✅ Relay - Drains the outbox to Kafka, then to Redis
+// Delivery to Kafka is at-least-once: a crash between producing and recording it
+// produces the row again, so consumers deduplicate on the event-id header. Once
+// recorded, a row is never produced again, however often its Redis fan-out fails.
+type Relay struct {
+	Store           Store
+	Producer        infrastructure.Producer
+	RedisService    Publisher
+	BatchSize       int           // Rows claimed at a time
+	PollInterval    time.Duration // Wait between polls once the outbox is drained
+	Lease           time.Duration // How long claimed rows are hidden from other relays
+	RetryBackoff    time.Duration // Delay after the first failure, doubled on each further one
+	MaxAttempts     int           // Failures before a row is parked
+	Retention       time.Duration // How long sent rows are kept
+	CleanupInterval time.Duration
+}
+
+// ✅ Publisher - The part of *redis.RedisService the fan-out goes through
+type Publisher interface {
+	Publish(channel string, message string) error
+}
+
+var _ Publisher = (*redis.RedisService)(nil)
+
+// maxRetryBackoff caps the doubling of RetryBackoff
+const maxRetryBackoff = 5 * time.Minute
+
+// errUndecodable: The payload is not an envelope, so retrying cannot help
+var errUndecodable = errors.New("payload is not an event envelope")
+
+// ✅ NewRelay: Defaults overridden by OUTBOX_* environment variables
+func NewRelay(store Store, producer infrastructure.Producer, redisService Publisher) *Relay {
+	return &Relay{
+		Store:           store,
+		Producer:        producer,
+		RedisService:    redisService,
+		BatchSize:       config.GetEnvInt("OUTBOX_BATCH_SIZE", 100),
+		PollInterval:    config.GetEnvDuration("OUTBOX_POLL_INTERVAL", 500*time.Millisecond),
+		Lease:           config.GetEnvDuration("OUTBOX_LEASE", time.Minute),
+		RetryBackoff:    config.GetEnvDuration("OUTBOX_RETRY_BACKOFF", time.Second),
+		MaxAttempts:     config.GetEnvInt("OUTBOX_MAX_ATTEMPTS", 20),
+		Retention:       config.GetEnvDuration("OUTBOX_RETENTION", 24*time.Hour),
+		CleanupInterval: config.GetEnvDuration("OUTBOX_CLEANUP_INTERVAL", 10*time.Minute),
+	}
+}
+
+// ✅ Run: Relays until ctx is cancelled
+func (r *Relay) Run(ctx context.Context) {
+	middleware.Log.Info("🚀 Outbox relay started")
+	poll := time.NewTicker(r.PollInterval)
+	defer poll.Stop()
+	cleanup := time.NewTicker(r.CleanupInterval)
+	defer cleanup.Stop()
+
+	for {
+		sent, err := r.RelayBatch(ctx)
+		if err != nil && ctx.Err() == nil {
+			middleware.Log.WithFields(logrus.Fields{"error": err}).Error("❌ Outbox relay batch failed")
+		}
+		if err == nil && sent == r.BatchSize {
+			continue // ✅ More rows are likely waiting
+		}
+
+		select {
+		case <-ctx.Done():
+			middleware.Log.Info("Outbox relay stopped")
+			return
+		case <-cleanup.C:
+			if _, err := r.Cleanup(ctx); err != nil {
+				middleware.Log.WithFields(logrus.Fields{"error": err}).Error("❌ Outbox cleanup failed")
+			}
+		case <-poll.C:
+		}
+	}
+}
+
+// ✅ RelayBatch: Claims the oldest due rows and delivers them in order
+// Claimed rows are leased, so other relays skip them while Kafka and Redis are
+// called. A row that fails is retried after a backoff and
+// parked after MaxAttempts failures; until it reaches Kafka, later rows with the
+// same topic and key wait, so per-key order holds. Other rows carry on.
+func (r *Relay) RelayBatch(ctx context.Context) (int, error) {
+	batch, err := r.Store.Claim(ctx, r.BatchSize, r.Lease)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	blocked := make(map[string]bool) // ✅ topic/key of rows that did not reach Kafka
+	for _, msg := range batch {
+		orderKey := msg.Topic + "/" + msg.Key
+		if msg.Key != "" && blocked[orderKey] {
+			// ✅ Hand it back; the claim skips it until the earlier row is in Kafka
+			if err := r.Store.Release(ctx, msg.ID); err != nil {
+				return sent, err
+			}
+			continue
+		}
+
+		if !msg.KafkaSent {
+			if err := r.produce(msg); err != nil {
+				blocked[orderKey] = true
+				if err := r.fail(ctx, msg, "kafka", err, false); err != nil {
+					return sent, err
+				}
+				continue
+			}
+			msg.KafkaSent = true
+		}
+
+		if err := r.fanOut(msg); err != nil {
+			if err := r.fail(ctx, msg, "redis", err, errors.Is(err, errUndecodable)); err != nil {
+				return sent, err
+			}
+			continue
+		}
+		if err := r.Store.MarkSent(ctx, msg.ID); err != nil {
+			return sent, err
+		}
+		sent++
+	}
+	return sent, nil
+}
+
+// fail: Schedules the next attempt, or parks the row once it is out of attempts
+// or can never succeed
+func (r *Relay) fail(ctx context.Context, msg Message, stage string, cause error, permanent bool) error {
+	attempts := msg.Attempts + 1
+	park := permanent || attempts >= r.MaxAttempts
+	fields := logrus.Fields{
+		"error":    cause,
+		"id":       msg.ID,
+		"topic":    msg.Topic,
+		"stage":    stage,
+		"attempts": attempts,
+	}
+	if park {
+		middleware.Log.WithFields(fields).Error("❌ Outbox message parked")
+	} else {
+		middleware.Log.WithFields(fields).Warn("⚠️ Outbox message not delivered, will retry")
+	}
+
+	return r.Store.Fail(ctx, msg.ID, Failure{
+		Attempts:  attempts,
+		Error:     stage + ": " + cause.Error(),
+		KafkaSent: msg.KafkaSent,
+		RetryIn:   r.RetryDelay(attempts),
+		Park:      park,
+	})
+}
+
+// ✅ RetryDelay: RetryBackoff doubled for every failure after the first, up to five minutes
+func (r *Relay) RetryDelay(attempts int) time.Duration {
+	delay := r.RetryBackoff
+	for i := 1; i < attempts && delay < maxRetryBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, maxRetryBackoff)
+}
+
+// produce: Kafka is the durable record, so it goes first
+func (r *Relay) produce(msg Message) error {
+	return r.Producer.Produce(infrastructure.Message{
+		Topic:   msg.Topic,
+		Key:     msg.Key,
+		Value:   msg.Payload,
+		Headers: msg.Headers,
+	})
+}
+
+// fanOut: The reply to the issuing connection and the broadcast for subscribers
+func (r *Relay) fanOut(msg Message) error {
+	var envelope events.Envelope
+	if err := json.Unmarshal(msg.Payload, &envelope); err != nil {
+		return fmt.Errorf("%w: %v", errUndecodable, err)
+	}
+
+	if connectionID := msg.Headers.Get(infrastructure.HeaderConnectionID); connectionID != "" {
+		if err := r.publish(events.ReplyChannel, events.RedisMessage{ConnectionID: connectionID, Envelope: envelope}); err != nil {
+			return err
+		}
+	}
+
+	envelope.CorrelationID = "" // ✅ Broadcasts don't answer a request
+	return r.publish(envelope.Name(), events.RedisMessage{
+		Tenant:   msg.Headers.Get(infrastructure.HeaderUserTenant), // ✅ The tenant of the command that caused the event
+		Envelope: envelope,
+	})
+}
+
+func (r *Relay) publish(channel string, message events.RedisMessage) error {
+	data, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+	return r.RedisService.Publish(channel, string(data))
+}
+
+// ✅ Cleanup: Deletes rows sent more than Retention ago; parked rows stay for inspection
+func (r *Relay) Cleanup(ctx context.Context) (int64, error) {
+	deleted, err := r.Store.DeleteSent(ctx, time.Now().Add(-r.Retention))
+	if err != nil {
+		return 0, err
+	}
+	if deleted > 0 {
+		middleware.Log.WithFields(logrus.Fields{"deleted": deleted}).Info("🧹 Cleaned up sent outbox rows")
+	}
+	return deleted, nil
+}
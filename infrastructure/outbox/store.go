@@ -0,0 +1,127 @@
+package outbox
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ✅ Store - Where the relay leases outbox rows and records what became of them
+type Store interface {
+	// Claim leases up to limit due rows, oldest first, skipping rows behind an
+	// earlier row with the same topic and key that is still waiting for Kafka
+	Claim(ctx context.Context, limit int, lease time.Duration) ([]Message, error)
+	// Release makes a claimed row due again without counting an attempt
+	Release(ctx context.Context, id int64) error
+	// MarkSent records that the row reached Kafka and Redis
+	MarkSent(ctx context.Context, id int64) error
+	// Fail records a failed attempt
+	Fail(ctx context.Context, id int64, failure Failure) error
+	// DeleteSent removes rows sent before the given time, returning how many
+	DeleteSent(ctx context.Context, before time.Time) (int64, error)
+}
+
+// ✅ Failure - What the relay records about a failed attempt
+type Failure struct {
+	Attempts  int
+	Error     string
+	KafkaSent bool          // The row reached Kafka before failing
+	RetryIn   time.Duration // Delay before the row is due again
+	Park      bool          // Give up on the row
+}
+
+// ✅ PostgresStore - The outbox table
+type PostgresStore struct {
+	DB *pgxpool.Pool
+}
+
+func NewPostgresStore(db *pgxpool.Pool) *PostgresStore {
+	return &PostgresStore{DB: db}
+}
+
+// Claim: Rows are leased in a short transaction, so no lock is held while Kafka and
+// Redis are called. The advisory lock serialises concurrent claims, so two relays
+// never lease rows of one key at once.
+func (s *PostgresStore) Claim(ctx context.Context, limit int, lease time.Duration) ([]Message, error) {
+	tx, err := s.DB.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtext('outbox_relay_claim'))`); err != nil {
+		return nil, err
+	}
+	rows, err := tx.Query(ctx,
+		`WITH due AS (
+		     SELECT o.id FROM outbox o
+		     WHERE o.sent_at IS NULL AND o.parked_at IS NULL AND o.next_attempt_at <= now()
+		       AND (o.message_key = '' OR NOT EXISTS (
+		           SELECT 1 FROM outbox e
+		           WHERE e.topic = o.topic AND e.message_key = o.message_key AND e.id < o.id
+		             AND e.kafka_sent_at IS NULL AND e.parked_at IS NULL AND e.next_attempt_at > now()))
+		     ORDER BY o.id LIMIT $1)
+		 UPDATE outbox SET next_attempt_at = now() + make_interval(secs => $2)
+		 FROM due WHERE outbox.id = due.id
+		 RETURNING outbox.id, topic, message_key, payload, headers, attempts, kafka_sent_at IS NOT NULL`,
+		limit, lease.Seconds(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	var batch []Message
+	for rows.Next() {
+		var msg Message
+		if err := rows.Scan(&msg.ID, &msg.Topic, &msg.Key, &msg.Payload, &msg.Headers, &msg.Attempts, &msg.KafkaSent); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		batch = append(batch, msg)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(batch, func(i, j int) bool { return batch[i].ID < batch[j].ID }) // ✅ RETURNING has no order
+	return batch, nil
+}
+
+func (s *PostgresStore) Release(ctx context.Context, id int64) error {
+	_, err := s.DB.Exec(ctx, `UPDATE outbox SET next_attempt_at = now() WHERE id = $1`, id)
+	return err
+}
+
+func (s *PostgresStore) MarkSent(ctx context.Context, id int64) error {
+	_, err := s.DB.Exec(ctx,
+		`UPDATE outbox SET kafka_sent_at = COALESCE(kafka_sent_at, now()), sent_at = now(), last_error = NULL WHERE id = $1`,
+		id,
+	)
+	return err
+}
+
+func (s *PostgresStore) Fail(ctx context.Context, id int64, failure Failure) error {
+	_, err := s.DB.Exec(ctx,
+		`UPDATE outbox SET attempts = $2, last_error = $3,
+		     kafka_sent_at = CASE WHEN $4 THEN COALESCE(kafka_sent_at, now()) ELSE kafka_sent_at END,
+		     next_attempt_at = now() + make_interval(secs => $5),
+		     parked_at = CASE WHEN $6 THEN now() ELSE NULL END
+		 WHERE id = $1`,
+		id, failure.Attempts, failure.Error, failure.KafkaSent, failure.RetryIn.Seconds(), failure.Park,
+	)
+	return err
+}
+
+// DeleteSent: Parked rows are never sent, so they stay for inspection
+func (s *PostgresStore) DeleteSent(ctx context.Context, before time.Time) (int64, error) {
+	tag, err := s.DB.Exec(ctx, `DELETE FROM outbox WHERE sent_at IS NOT NULL AND sent_at < $1`, before)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
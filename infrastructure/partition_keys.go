@@ -0,0 +1,133 @@
+package infrastructure
+
+import (
+	"GoSyntaxDoc/domain/topics"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"strings"
+	"sync"
+)
+
+// ✅ KeySource - Everything a key strategy may derive a partition key from
+type KeySource struct {
+	Value        []byte // JSON message body
+	ConnectionID string
+	Tenant       string
+}
+
+// ✅ KeyStrategy - Derives the partition key for a message; messages with the
+// same key land on the same partition and keep their relative order
+type KeyStrategy func(src KeySource) (string, error)
+
+// ✅ FieldKey keys by a dotted path into the JSON body, e.g. "data.user_id"
+func FieldKey(path string) KeyStrategy {
+	segments := strings.Split(path, ".")
+	return func(src KeySource) (string, error) {
+		decoder := json.NewDecoder(bytes.NewReader(src.Value))
+		decoder.UseNumber() // ✅ Keep 12345678901 from turning into 1.2345678901e+10
+
+		var current interface{}
+		if err := decoder.Decode(&current); err != nil {
+			return "", fmt.Errorf("key %s: %w", path, err)
+		}
+		for _, segment := range segments {
+			object, ok := current.(map[string]interface{})
+			if !ok {
+				return "", fmt.Errorf("key %s: %q is not an object", path, segment)
+			}
+			if current, ok = object[segment]; !ok {
+				return "", fmt.Errorf("key %s: missing %q", path, segment)
+			}
+		}
+
+		switch v := current.(type) {
+		case string:
+			return v, nil
+		case json.Number:
+			return v.String(), nil
+		case bool:
+			return fmt.Sprint(v), nil
+		default:
+			return "", fmt.Errorf("key %s: unsupported value type %T", path, current)
+		}
+	}
+}
+
+// ✅ ConnectionKey keeps every message of one WebSocket session in order
+func ConnectionKey() KeyStrategy {
+	return func(src KeySource) (string, error) {
+		if src.ConnectionID == "" {
+			return "", fmt.Errorf("key: no connection ID")
+		}
+		return src.ConnectionID, nil
+	}
+}
+
+// ✅ TenantHashKey keeps each tenant's messages in order without exposing the tenant name
+func TenantHashKey() KeyStrategy {
+	return func(src KeySource) (string, error) {
+		if src.Tenant == "" {
+			return "", fmt.Errorf("key: no tenant")
+		}
+		h := fnv.New64a()
+		h.Write([]byte(src.Tenant))
+		return fmt.Sprintf("%016x", h.Sum64()), nil
+	}
+}
+
+// ✅ FirstOf tries each strategy in turn and uses the first key found
+func FirstOf(strategies ...KeyStrategy) KeyStrategy {
+	return func(src KeySource) (string, error) {
+		var lastErr error
+		for _, strategy := range strategies {
+			key, err := strategy(src)
+			if err == nil && key != "" {
+				return key, nil
+			}
+			lastErr = err
+		}
+		return "", lastErr
+	}
+}
+
+// ✅ KeyStrategies - Per-topic key strategies shared by every producer
+// Topics without a strategy, and messages the strategy cannot key, get an empty
+// key and are spread across partitions with no ordering guarantee.
+type KeyStrategies struct {
+	mu      sync.RWMutex
+	byTopic map[string]KeyStrategy
+}
+
+func NewKeyStrategies() *KeyStrategies {
+	return &KeyStrategies{byTopic: make(map[string]KeyStrategy)}
+}
+
+func (k *KeyStrategies) Register(topic string, strategy KeyStrategy) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.byTopic[topic] = strategy
+}
+
+// ✅ Key returns the partition key for a message on the topic
+func (k *KeyStrategies) Key(topic string, src KeySource) (string, error) {
+	k.mu.RLock()
+	strategy, ok := k.byTopic[topic]
+	k.mu.RUnlock()
+	if !ok {
+		return "", nil
+	}
+	return strategy(src)
+}
+
+// ✅ DefaultKeyStrategies: Ordering per user where the user is known, otherwise per session or tenant
+// Commands carry their data under "data"; user events are envelopes carrying the user under "payload".
+func DefaultKeyStrategies() *KeyStrategies {
+	keys := NewKeyStrategies()
+	keys.Register(topics.UserCreated, FirstOf(TenantHashKey(), ConnectionKey()))
+	keys.Register(topics.UserFetch, FieldKey("data.user_id"))
+	keys.Register(topics.UserRead, FirstOf(TenantHashKey(), ConnectionKey()))
+	keys.Register(topics.UserEvents, FieldKey("payload.id"))
+	return keys
+}
@@ -1,8 +1,13 @@
 package redis
 
 import (
+	"GoSyntaxDoc/config"
 	"GoSyntaxDoc/presentation/middleware"
 	"context"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/redis/go-redis/v9"
 	"github.com/sirupsen/logrus"
@@ -11,12 +16,19 @@ import (
 type RedisService struct {
 	client *redis.Client
 	ctx    context.Context
+	closed atomic.Bool // Set by Close; receive loops stop instead of retrying
 }
 
+// Bounds on the pause between receive attempts while Redis is unreachable
+const (
+	minReceiveRetry = 100 * time.Millisecond
+	maxReceiveRetry = 5 * time.Second
+)
+
 func NewRedisService() *RedisService {
 	ctx := context.Background()
 	client := redis.NewClient(&redis.Options{
-		Addr:     "redis:6379",
+		Addr:     config.GetEnv("REDIS_ADDR", "redis:6379"),
 		Password: "", // no password set
 		DB:       0,  // use default DB
 	})
@@ -39,31 +51,90 @@ func (rs *RedisService) Publish(channel string, message string) error {
 	return nil
 }
 
-func (r *RedisService) Subscribe(channel string, messageHandler func(msg string)) {
-	pubsub := r.client.Subscribe(r.ctx, channel)
+// ✅ Subscription - Handle returned by Subscribe so callers can stop listening
+type Subscription struct {
+	mu     sync.Mutex
+	pubsub *redis.PubSub
+	closed atomic.Bool
+}
 
-	go func() {
-		for {
-			msg, err := pubsub.ReceiveMessage(r.ctx)
-			if err != nil {
-				middleware.Log.WithFields(logrus.Fields{"error": err}).Error("❌ Redis Subscription Error")
+// ✅ Close: Unsubscribes and stops the receive loop
+func (s *Subscription) Close() error {
+	s.closed.Store(true)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.pubsub.Close()
+}
 
-				// ✅ If Redis client is closed, reconnect automatically
-				if err == redis.ErrClosed {
-					middleware.Log.Warn("⚠️ Redis connection lost. Reconnecting...")
-					pubsub = r.client.Subscribe(r.ctx, channel)
-					continue
-				}
-				break // Exit on unexpected errors
+func (s *Subscription) current() *redis.PubSub {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.pubsub
+}
+
+// Subscribe listens on a channel; names containing '*' are subscribed as patterns
+// It does not wait for Redis: the subscription is retried until Close is called.
+func (r *RedisService) Subscribe(channel string, messageHandler func(msg string)) *Subscription {
+	sub := &Subscription{pubsub: r.subscribe(channel)}
+	go r.receive(sub, channel, messageHandler)
+	return sub
+}
+
+// ✅ SubscribeConfirmed: Subscribe, returning once Redis has confirmed the subscription
+// On error nothing is left subscribed.
+func (r *RedisService) SubscribeConfirmed(ctx context.Context, channel string, messageHandler func(msg string)) (*Subscription, error) {
+	pubsub := r.subscribe(channel)
+	if _, err := pubsub.Receive(ctx); err != nil {
+		_ = pubsub.Close()
+		return nil, err
+	}
+	sub := &Subscription{pubsub: pubsub}
+	go r.receive(sub, channel, messageHandler)
+	return sub, nil
+}
+
+// receive hands messages to messageHandler until the subscription or the service is closed
+// go-redis reconnects and resubscribes on the next receive after a connection error,
+// so errors only pause the loop; they never end it.
+func (r *RedisService) receive(sub *Subscription, channel string, messageHandler func(msg string)) {
+	retry := minReceiveRetry
+	for {
+		msg, err := sub.current().ReceiveMessage(r.ctx)
+		if err != nil {
+			if sub.closed.Load() || r.closed.Load() {
+				return // ✅ Closed by the caller
 			}
+			middleware.Log.WithFields(logrus.Fields{"error": err, "channel": channel}).Error("❌ Redis Subscription Error")
 
-			// ✅ Process message asynchronously
-			go messageHandler(msg.Payload)
+			// ✅ A closed PubSub cannot recover; replace it
+			if err == redis.ErrClosed {
+				middleware.Log.Warn("⚠️ Redis connection lost. Reconnecting...")
+				sub.mu.Lock()
+				if !sub.closed.Load() { // ✅ Close may have run since the check above
+					sub.pubsub = r.subscribe(channel)
+				}
+				sub.mu.Unlock()
+			}
+			time.Sleep(retry)
+			retry = min(retry*2, maxReceiveRetry)
+			continue
 		}
-	}()
+		retry = minReceiveRetry
+
+		// ✅ Process message asynchronously
+		go messageHandler(msg.Payload)
+	}
+}
+
+func (r *RedisService) subscribe(channel string) *redis.PubSub {
+	if strings.Contains(channel, "*") {
+		return r.client.PSubscribe(r.ctx, channel)
+	}
+	return r.client.Subscribe(r.ctx, channel)
 }
 
 func (rs *RedisService) Close() {
+	rs.closed.Store(true)
 	rs.client.Close()
 	middleware.Log.Info("Redis connection closed")
 }
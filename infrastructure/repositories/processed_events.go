@@ -0,0 +1,45 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// ✅ ClaimEvent: Records within tx that consumer is applying eventID
+// Returns false and the stored result if the event was already applied. A
+// concurrent delivery of the same event waits here until the first one's
+// transaction ends, then sees its result.
+func ClaimEvent(ctx context.Context, tx pgx.Tx, consumer string, eventID string) (bool, []byte, error) {
+	tag, err := tx.Exec(ctx,
+		`INSERT INTO processed_events (consumer, event_id) VALUES ($1, $2) ON CONFLICT DO NOTHING`,
+		consumer, eventID,
+	)
+	if err != nil {
+		return false, nil, err
+	}
+	if tag.RowsAffected() == 1 {
+		return true, nil, nil
+	}
+
+	var result []byte
+	err = tx.QueryRow(ctx,
+		`SELECT result FROM processed_events WHERE consumer = $1 AND event_id = $2`,
+		consumer, eventID,
+	).Scan(&result)
+	return false, result, err
+}
+
+// ✅ RecordEventResult: Stores what applying a claimed event produced, for replaying to duplicates
+func RecordEventResult(ctx context.Context, tx pgx.Tx, consumer string, eventID string, result interface{}) error {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(ctx,
+		`UPDATE processed_events SET result = $3 WHERE consumer = $1 AND event_id = $2`,
+		consumer, eventID, data,
+	)
+	return err
+}
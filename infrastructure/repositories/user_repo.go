@@ -2,9 +2,12 @@ package repositories
 
 import (
 	"GoSyntaxDoc/domain/entities"
+	"GoSyntaxDoc/domain/topics"
 	"GoSyntaxDoc/infrastructure/database"
+	"GoSyntaxDoc/infrastructure/outbox"
 	"GoSyntaxDoc/presentation/middleware"
 	"context"
+	"encoding/json"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
@@ -53,21 +56,51 @@ func (repo *UserRepository) FetchUserById(userId int) (*entities.User, error) {
 	return &user, nil
 }
 
+// CreateUserConsumer names the user creation in processed_events
+const CreateUserConsumer = topics.UserCreated
+
 // ✅ Create User (Updated for pgx)
-func (repo *UserRepository) CreateUser(first_name string, last_name string) (*entities.User, error) {
+// The event returned by newEvent is written to the outbox in the same transaction,
+// so the user and the record of its creation are committed together or not at all.
+// With an eventID, a repeated call for the same event creates nothing and returns
+// the user created the first time, with created set to false.
+func (repo *UserRepository) CreateUser(eventID string, first_name string, last_name string, newEvent func(*entities.User) (outbox.Message, error)) (user *entities.User, created bool, err error) {
 	ctx := context.Background()
 	query := `INSERT INTO users (first_name, last_name) VALUES ($1, $2) RETURNING id, created_at`
 
-	var user entities.User
+	tx, err := repo.DB.Begin(ctx)
+	if err != nil {
+		middleware.Log.WithFields(logrus.Fields{"error": err}).Error("Database Transaction Error")
+		return nil, false, err
+	}
+	defer tx.Rollback(ctx) // ✅ No-op after Commit
+
+	if eventID != "" {
+		claimed, result, err := ClaimEvent(ctx, tx, CreateUserConsumer, eventID)
+		if err != nil {
+			middleware.Log.WithFields(logrus.Fields{"error": err}).Error("Database Query Error")
+			return nil, false, err
+		}
+		if !claimed {
+			middleware.Log.WithFields(logrus.Fields{"event_id": eventID}).Info("♻️ User creation already applied")
+			var previous entities.User
+			if err := json.Unmarshal(result, &previous); err != nil {
+				return nil, false, err
+			}
+			return &previous, false, nil
+		}
+	}
+
+	user = &entities.User{}
 	var createdAt pgtype.Timestamp // ✅ Use pgx.NullTime instead of sql.NullTime
 
-	err := repo.DB.QueryRow(ctx, query, first_name, last_name).Scan(
+	err = tx.QueryRow(ctx, query, first_name, last_name).Scan(
 		&user.ID, &createdAt,
 	)
 
 	if err != nil {
 		middleware.Log.WithFields(logrus.Fields{"error": err}).Error("Database Query Error")
-		return nil, err
+		return nil, false, err
 	}
 
 	// ✅ Convert NULL timestamps to zero-value JSONTime
@@ -80,7 +113,26 @@ func (repo *UserRepository) CreateUser(first_name string, last_name string) (*en
 	user.FirstName = first_name
 	user.LastName = last_name
 
-	return &user, nil
+	event, err := newEvent(user)
+	if err != nil {
+		return nil, false, err
+	}
+	if err := outbox.Insert(ctx, tx, event); err != nil {
+		middleware.Log.WithFields(logrus.Fields{"error": err}).Error("Outbox Insert Error")
+		return nil, false, err
+	}
+	if eventID != "" {
+		if err := RecordEventResult(ctx, tx, CreateUserConsumer, eventID, user); err != nil {
+			middleware.Log.WithFields(logrus.Fields{"error": err}).Error("Database Query Error")
+			return nil, false, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		middleware.Log.WithFields(logrus.Fields{"error": err}).Error("Database Transaction Error")
+		return nil, false, err
+	}
+	return user, true, nil
 }
 
 func (repo *UserRepository) FetchAllUsers() ([]entities.User, error) {
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"GoSyntaxDoc/infrastructure/kafkaadmin"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// ✅ runGroups: groups list | describe | lag | reset
+func runGroups(ctx context.Context, admin *kafkaadmin.Admin, wait time.Duration, args []string) int {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, usage)
+		return exitError
+	}
+	command := args[0]
+	flags := flag.NewFlagSet("groups "+command, flag.ContinueOnError)
+	group := flags.String("group", "", "consumer group ID, e.g. user-service-group")
+	topicList := flags.String("topic", "", "reset: comma-separated topics (default: every topic the group has committed on)")
+	toEarliest := flags.Bool("to-earliest", false, "reset: to the earliest offset still on the broker")
+	toLatest := flags.Bool("to-latest", false, "reset: to the end, skipping everything not yet handled")
+	toDatetime := flags.String("to-datetime", "", "reset: to the first message at or after an RFC 3339 time")
+	toOffset := flags.Int64("to-offset", -1, "reset: to this offset on every partition")
+	execute := flags.Bool("execute", false, "reset: commit the offsets instead of only printing them")
+	if err := flags.Parse(args[1:]); err != nil {
+		return parseExit(err)
+	}
+
+	switch command {
+	case "list":
+	case "describe", "lag", "reset":
+		if *group == "" {
+			log.Printf("❌ groups %s needs -group", command)
+			return exitError
+		}
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		return exitError
+	}
+
+	var target kafkaadmin.ResetTarget
+	if command == "reset" {
+		var err error
+		if target, err = resetTarget(*toEarliest, *toLatest, *toDatetime, *toOffset); err != nil {
+			log.Printf("❌ %v", err)
+			return exitError
+		}
+	}
+
+	if err := waitForKafka(ctx, admin, wait); err != nil {
+		log.Printf("❌ %v", err)
+		return exitError
+	}
+
+	var err error
+	switch command {
+	case "list":
+		err = listGroups(ctx, admin)
+	case "describe":
+		err = describeGroup(ctx, admin, *group)
+	case "lag":
+		err = showLag(ctx, admin, *group)
+	case "reset":
+		err = resetOffsets(ctx, admin, *group, splitList(*topicList), target, *execute)
+	}
+	if err != nil {
+		log.Printf("❌ %v", err)
+		return exitError
+	}
+	return exitOK
+}
+
+func listGroups(ctx context.Context, admin *kafkaadmin.Admin) error {
+	groups, err := admin.Groups(ctx)
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "GROUP\tSTATE\tMEMBERS")
+	for _, group := range groups {
+		fmt.Fprintf(w, "%s\t%s\t%d\n", group.ID, group.State, len(group.Members))
+	}
+	return w.Flush()
+}
+
+func describeGroup(ctx context.Context, admin *kafkaadmin.Admin, id string) error {
+	group, err := admin.DescribeGroup(ctx, id)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Group %s is %s with %d member(s)\n\n", group.ID, group.State, len(group.Members))
+	if !group.Active() {
+		return nil
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "MEMBER\tCLIENT\tHOST\tASSIGNMENTS")
+	for _, member := range group.Members {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", member.ID, member.ClientID, member.Host, formatAssignments(member.Assignments))
+	}
+	return w.Flush()
+}
+
+func showLag(ctx context.Context, admin *kafkaadmin.Admin, id string) error {
+	offsets, err := admin.Lag(ctx, id)
+	if err != nil {
+		return err
+	}
+	var total int64
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "TOPIC\tPARTITION\tCOMMITTED\tEND\tLAG\tMEMBER")
+	for _, partition := range offsets {
+		total += partition.Lag()
+		fmt.Fprintf(w, "%s\t%d\t%s\t%d\t%d\t%s\n", partition.Topic, partition.Partition,
+			formatOffset(partition.Committed), partition.End, partition.Lag(), orDash(partition.Member))
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	fmt.Printf("\nTotal lag: %d\n", total)
+	return nil
+}
+
+func resetOffsets(ctx context.Context, admin *kafkaadmin.Admin, id string, topics []string, target kafkaadmin.ResetTarget, execute bool) error {
+	changes, err := admin.PlanReset(ctx, id, topics, target)
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "TOPIC\tPARTITION\tCURRENT\tNEW")
+	for _, change := range changes {
+		fmt.Fprintf(w, "%s\t%d\t%s\t%d\n", change.Topic, change.Partition, formatOffset(change.From), change.To)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	if !execute {
+		fmt.Println("\nDry run; pass -execute to commit these offsets.")
+		return nil
+	}
+	if err := admin.ApplyReset(ctx, id, changes); err != nil {
+		return err
+	}
+	log.Printf("✅ Reset %d partition(s) of %s", len(changes), id)
+	return nil
+}
+
+// resetTarget: Exactly one of the -to-* flags
+func resetTarget(earliest bool, latest bool, datetime string, offset int64) (kafkaadmin.ResetTarget, error) {
+	var targets []kafkaadmin.ResetTarget
+	if earliest {
+		targets = append(targets, kafkaadmin.ResetTarget{Kind: kafkaadmin.ResetEarliest})
+	}
+	if latest {
+		targets = append(targets, kafkaadmin.ResetTarget{Kind: kafkaadmin.ResetLatest})
+	}
+	if datetime != "" {
+		at, err := time.Parse(time.RFC3339, datetime)
+		if err != nil {
+			return kafkaadmin.ResetTarget{}, fmt.Errorf("-to-datetime: %w", err)
+		}
+		targets = append(targets, kafkaadmin.ResetTarget{Kind: kafkaadmin.ResetTimestamp, Time: at})
+	}
+	if offset >= 0 {
+		targets = append(targets, kafkaadmin.ResetTarget{Kind: kafkaadmin.ResetOffset, Offset: offset})
+	}
+	if len(targets) != 1 {
+		return kafkaadmin.ResetTarget{}, errors.New("groups reset needs exactly one of -to-earliest, -to-latest, -to-datetime or -to-offset")
+	}
+	return targets[0], nil
+}
+
+// formatAssignments: e.g. "user.created[0,1] user.fetch[0]"
+func formatAssignments(assignments map[string][]int) string {
+	topics := make([]string, 0, len(assignments))
+	for topic := range assignments {
+		topics = append(topics, topic)
+	}
+	sort.Strings(topics)
+	parts := make([]string, 0, len(topics))
+	for _, topic := range topics {
+		partitions := append([]int(nil), assignments[topic]...)
+		sort.Ints(partitions)
+		ids := make([]string, 0, len(partitions))
+		for _, partition := range partitions {
+			ids = append(ids, strconv.Itoa(partition))
+		}
+		parts = append(parts, topic+"["+strings.Join(ids, ",")+"]")
+	}
+	return orDash(strings.Join(parts, " "))
+}
+
+func formatOffset(offset int64) string {
+	if offset < 0 {
+		return "-"
+	}
+	return strconv.FormatInt(offset, 10)
+}
+
+func orDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
+
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
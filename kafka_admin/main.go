@@ -0,0 +1,89 @@
+package main
+
+import (
+	"GoSyntaxDoc/config"
+	"GoSyntaxDoc/infrastructure/kafkaadmin"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// ✅ Exit codes, so deploy pipelines can tell drift from failure
+const (
+	exitOK    = 0
+	exitError = 1
+	exitDrift = 2 // "topics plan" found changes to make
+)
+
+const usage = `Usage: kafka-admin [-brokers host:port,...] [-wait 30s] <command>
+
+Commands:
+  topics plan   [-spec file]  Print the changes the cluster needs; exits 2 if there are any
+  topics apply  [-spec file]  Make those changes
+  topics export [-spec file]  Print the spec as YAML, e.g. to start a spec file from the catalog
+
+  groups list                 Consumer groups, their state and member count
+  groups describe -group id   Members and the partitions assigned to each
+  groups lag      -group id   Committed and end offsets and lag per partition
+  groups reset    -group id [-topic a,b] (-to-earliest | -to-latest |
+                  -to-datetime 2006-01-02T15:04:05Z | -to-offset n) [-execute]
+                              Preview moving the group's offsets; -execute commits them
+
+Without -spec the topics come from the catalog in domain/topics. Offsets can
+only be reset while the group has no active members.
+`
+
+func main() {
+	os.Exit(run(os.Args[1:]))
+}
+
+func run(args []string) int {
+	global := flag.NewFlagSet("kafka-admin", flag.ContinueOnError)
+	brokers := global.String("brokers", config.GetEnv("KAFKA_BROKER", "kafka:9092"), "comma-separated bootstrap brokers")
+	wait := global.Duration("wait", 30*time.Second, "how long to wait for Kafka to answer")
+	global.Usage = func() { fmt.Fprint(global.Output(), usage) }
+	if err := global.Parse(args); err != nil {
+		return parseExit(err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	admin := kafkaadmin.NewAdmin(strings.Split(*brokers, ",")...)
+
+	switch global.Arg(0) {
+	case "topics":
+		return runTopics(ctx, admin, *wait, global.Args()[1:])
+	case "groups":
+		return runGroups(ctx, admin, *wait, global.Args()[1:])
+	default:
+		global.Usage()
+		return exitError
+	}
+}
+
+// waitForKafka: Kafka may still be starting when the container runs
+func waitForKafka(ctx context.Context, admin *kafkaadmin.Admin, wait time.Duration) error {
+	log.Println("⏳ Waiting for Kafka to be ready...")
+	ctx, cancel := context.WithTimeout(ctx, wait)
+	defer cancel()
+	if err := admin.WaitUntilReady(ctx, 3*time.Second); err != nil {
+		return err
+	}
+	log.Println("✅ Kafka is ready!")
+	return nil
+}
+
+// parseExit: -h is not an error
+func parseExit(err error) int {
+	if errors.Is(err, flag.ErrHelp) {
+		return exitOK
+	}
+	return exitError
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"GoSyntaxDoc/config"
+	"GoSyntaxDoc/infrastructure/kafkaadmin"
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+)
+
+// ✅ runTopics: topics plan | apply | export
+func runTopics(ctx context.Context, admin *kafkaadmin.Admin, wait time.Duration, args []string) int {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, usage)
+		return exitError
+	}
+	command := args[0]
+	flags := flag.NewFlagSet("topics "+command, flag.ContinueOnError)
+	specPath := flags.String("spec", config.GetEnv("KAFKA_TOPIC_SPEC", ""), "YAML or JSON topic spec (default: the topic catalog)")
+	if err := flags.Parse(args[1:]); err != nil {
+		return parseExit(err)
+	}
+
+	spec, err := kafkaadmin.LoadSpec(*specPath)
+	if err != nil {
+		log.Printf("❌ %v", err)
+		return exitError
+	}
+
+	switch command {
+	case "export":
+		data, err := spec.Marshal()
+		if err != nil {
+			log.Printf("❌ %v", err)
+			return exitError
+		}
+		os.Stdout.Write(data)
+		return exitOK
+	case "plan", "apply":
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		return exitError
+	}
+
+	if err := waitForKafka(ctx, admin, wait); err != nil {
+		log.Printf("❌ %v", err)
+		return exitError
+	}
+	plan, err := admin.Plan(ctx, spec)
+	if err != nil {
+		log.Printf("❌ Failed to read topics: %v", err)
+		return exitError
+	}
+	fmt.Print(plan)
+
+	if command == "plan" {
+		if plan.Drift() {
+			return exitDrift
+		}
+		return exitOK
+	}
+	if !plan.Drift() {
+		return exitOK
+	}
+	if err := admin.Apply(ctx, plan); err != nil {
+		log.Printf("❌ Failed to apply topic changes:\n%v", err)
+		return exitError
+	}
+	log.Println("✅ Kafka topics setup completed!")
+	return exitOK
+}
@@ -0,0 +1,226 @@
+package websocket
+
+import (
+	"GoSyntaxDoc/domain/entities"
+	"GoSyntaxDoc/presentation/middleware"
+	"errors"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gofiber/contrib/websocket"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// ✅ FrameWriter - The writing half of a connection; *websocket.Conn in the gateway
+type FrameWriter interface {
+	WriteMessage(messageType int, data []byte) error
+	WriteControl(messageType int, data []byte, deadline time.Time) error
+	SetWriteDeadline(t time.Time) error
+	Close() error
+}
+
+// ✅ Client - A registered WebSocket connection identified by a gateway-assigned ID
+// All writes go through a bounded queue drained by the client's own writer goroutine,
+// so a slow peer only ever delays itself.
+type Client struct {
+	ID       string
+	Conn     FrameWriter
+	Identity *entities.Identity // Authenticated caller from the handshake token
+	channels map[string]bool    // Subscribed channels, guarded by WebSocketManager.mu
+
+	cfg       Config
+	send      chan []byte
+	enqueueMu sync.Mutex // Makes drop-oldest eviction atomic with the following enqueue
+	quit      chan struct{}
+	quitOnce  sync.Once
+	done      chan struct{} // Closed when the writer goroutine exits
+	closeCode int           // Close code written by the writer goroutine on quit, 0 for none
+	reason    string        // First recorded disconnect reason
+
+	connectedAt  time.Time
+	lastActivity atomic.Int64 // Unix nanos of the last inbound application frame
+	maxDepth     atomic.Int64
+	dropped      atomic.Int64
+}
+
+// ✅ Disconnect reasons recorded in the structured unregister log
+const (
+	ReasonClientClosed  = "client_closed"
+	ReasonPongTimeout   = "pong_timeout"
+	ReasonIdleTimeout   = "idle_timeout"
+	ReasonQueueOverflow = "queue_overflow"
+	ReasonReadError     = "read_error"
+	ReasonWriteError    = "write_error"
+)
+
+// ✅ Constructor Function: Nothing is written until Start
+func NewClient(conn FrameWriter, identity *entities.Identity, cfg Config) *Client {
+	cfg = cfg.withDefaults()
+	client := &Client{
+		ID:          uuid.NewString(),
+		Conn:        conn,
+		Identity:    identity,
+		channels:    make(map[string]bool),
+		cfg:         cfg,
+		send:        make(chan []byte, cfg.SendBufferSize),
+		quit:        make(chan struct{}),
+		done:        make(chan struct{}),
+		connectedAt: time.Now(),
+	}
+	client.touch()
+	return client
+}
+
+// ✅ Start: Runs the writer goroutine, which closes the connection when it stops
+func (c *Client) Start() {
+	go c.writePump()
+}
+
+// ✅ touch: Records inbound application activity for the idle timeout
+func (c *Client) touch() {
+	c.lastActivity.Store(time.Now().UnixNano())
+}
+
+func (c *Client) idleFor(now time.Time) time.Duration {
+	return now.Sub(time.Unix(0, c.lastActivity.Load()))
+}
+
+// ✅ Enqueue: Queues a frame for the writer goroutine without blocking the caller
+func (c *Client) Enqueue(frame []byte) {
+	c.enqueueMu.Lock()
+	defer c.enqueueMu.Unlock()
+
+	select {
+	case <-c.quit:
+		return
+	default:
+	}
+
+	for {
+		select {
+		case c.send <- frame:
+			c.recordDepth()
+			return
+		default:
+		}
+
+		switch c.cfg.OverflowPolicy {
+		case DropNewest:
+			c.recordDrop()
+			return
+		case Disconnect:
+			c.recordDrop()
+			c.stop(c.cfg.OverflowCloseCode, ReasonQueueOverflow)
+			return
+		default: // DropOldest
+			select {
+			case <-c.send:
+				c.recordDrop()
+			default:
+			}
+		}
+	}
+}
+
+// ✅ QueueDepth: Frames currently waiting to be written
+func (c *Client) QueueDepth() int {
+	return len(c.send)
+}
+
+// ✅ MaxQueueDepth: Highest queue depth observed on this connection
+func (c *Client) MaxQueueDepth() int64 {
+	return c.maxDepth.Load()
+}
+
+// ✅ Dropped: Frames discarded by the overflow policy
+func (c *Client) Dropped() int64 {
+	return c.dropped.Load()
+}
+
+func (c *Client) recordDepth() {
+	depth := int64(len(c.send))
+	for {
+		current := c.maxDepth.Load()
+		if depth <= current || c.maxDepth.CompareAndSwap(current, depth) {
+			return
+		}
+	}
+}
+
+func (c *Client) recordDrop() {
+	dropped := c.dropped.Add(1)
+	middleware.Log.WithFields(logrus.Fields{
+		"connection_id": c.ID,
+		"policy":        c.cfg.OverflowPolicy,
+		"queue_depth":   c.QueueDepth(),
+		"dropped":       dropped,
+	}).Warn("⚠️ WebSocket send queue full")
+}
+
+// stop asks the writer goroutine to send a close frame (if code != 0) and close the connection.
+// Only the first call takes effect, so the first reason recorded is the one logged.
+func (c *Client) stop(code int, reason string) {
+	c.quitOnce.Do(func() {
+		c.closeCode = code
+		c.reason = reason
+		close(c.quit)
+	})
+}
+
+// ✅ writePump: The only goroutine that writes to the connection; also drives pings and the idle timeout
+func (c *Client) writePump() {
+	ticker := time.NewTicker(c.cfg.PingInterval)
+	defer ticker.Stop()
+	defer close(c.done)
+	defer c.Conn.Close() // ✅ Unblocks the read loop so the connection is cleaned up
+
+	for {
+		select {
+		case frame := <-c.send:
+			_ = c.Conn.SetWriteDeadline(time.Now().Add(c.cfg.WriteWait))
+			if err := c.Conn.WriteMessage(websocket.TextMessage, frame); err != nil {
+				middleware.Log.WithFields(logrus.Fields{"error": err, "connection_id": c.ID}).Error("❌ Error writing message to WebSocket")
+				c.stop(0, ReasonWriteError)
+				return
+			}
+		case now := <-ticker.C:
+			if c.idleFor(now) >= c.cfg.IdleTimeout {
+				c.stop(websocket.CloseNormalClosure, ReasonIdleTimeout)
+				c.writeClose()
+				return
+			}
+			if err := c.Conn.WriteControl(websocket.PingMessage, nil, now.Add(c.cfg.WriteWait)); err != nil {
+				middleware.Log.WithFields(logrus.Fields{"error": err, "connection_id": c.ID}).Warn("⚠️ Error sending WebSocket ping")
+				c.stop(0, ReasonWriteError)
+				return
+			}
+		case <-c.quit:
+			c.writeClose()
+			return
+		}
+	}
+}
+
+func (c *Client) writeClose() {
+	if c.closeCode == 0 {
+		return
+	}
+	message := websocket.FormatCloseMessage(c.closeCode, c.reason)
+	_ = c.Conn.WriteControl(websocket.CloseMessage, message, time.Now().Add(c.cfg.WriteWait))
+}
+
+// ✅ readReason: Classifies the error that ended the read loop
+func readReason(err error) string {
+	var netErr net.Error
+	switch {
+	case websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway, websocket.CloseNoStatusReceived):
+		return ReasonClientClosed
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return ReasonPongTimeout // ✅ No pong or frame before the read deadline: dead or half-open peer
+	default:
+		return ReasonReadError
+	}
+}
@@ -0,0 +1,91 @@
+package websocket
+
+import (
+	"GoSyntaxDoc/config"
+	"time"
+
+	"github.com/gofiber/contrib/websocket"
+)
+
+// ✅ OverflowPolicy - What to do when a client's outbound queue is full
+type OverflowPolicy string
+
+const (
+	DropOldest OverflowPolicy = "drop_oldest" // Discard the oldest queued frame to make room
+	DropNewest OverflowPolicy = "drop_newest" // Discard the frame being enqueued
+	Disconnect OverflowPolicy = "disconnect"  // Close the connection with OverflowCloseCode
+)
+
+// ✅ Config - Tunables for the WebSocket gateway
+type Config struct {
+	SendBufferSize    int            // Outbound frames buffered per connection
+	OverflowPolicy    OverflowPolicy // Applied when the buffer is full
+	OverflowCloseCode int            // Close code used by the Disconnect policy
+
+	PingInterval time.Duration // How often the server pings each client
+	PongWait     time.Duration // Read deadline, extended by every pong or inbound frame
+	WriteWait    time.Duration // Deadline for a single frame write
+	IdleTimeout  time.Duration // Close connections that send no application frames for this long
+
+	SubscribeTimeout time.Duration // How long a channel subscription waits for Redis to confirm it
+}
+
+func DefaultConfig() Config {
+	return Config{
+		SendBufferSize:    256,
+		OverflowPolicy:    DropOldest,
+		OverflowCloseCode: websocket.ClosePolicyViolation,
+		PingInterval:      30 * time.Second,
+		PongWait:          60 * time.Second,
+		WriteWait:         10 * time.Second,
+		IdleTimeout:       10 * time.Minute,
+		SubscribeTimeout:  5 * time.Second,
+	}
+}
+
+// ✅ ConfigFromEnv: DefaultConfig overridden by WS_* environment variables
+func ConfigFromEnv() Config {
+	cfg := DefaultConfig()
+	cfg.SendBufferSize = config.GetEnvInt("WS_SEND_BUFFER_SIZE", cfg.SendBufferSize)
+	cfg.OverflowPolicy = OverflowPolicy(config.GetEnv("WS_OVERFLOW_POLICY", string(cfg.OverflowPolicy)))
+	cfg.OverflowCloseCode = config.GetEnvInt("WS_OVERFLOW_CLOSE_CODE", cfg.OverflowCloseCode)
+	cfg.PingInterval = config.GetEnvDuration("WS_PING_INTERVAL", cfg.PingInterval)
+	cfg.PongWait = config.GetEnvDuration("WS_PONG_WAIT", cfg.PongWait)
+	cfg.WriteWait = config.GetEnvDuration("WS_WRITE_WAIT", cfg.WriteWait)
+	cfg.IdleTimeout = config.GetEnvDuration("WS_IDLE_TIMEOUT", cfg.IdleTimeout)
+	cfg.SubscribeTimeout = config.GetEnvDuration("WS_SUBSCRIBE_TIMEOUT", cfg.SubscribeTimeout)
+	return cfg.withDefaults()
+}
+
+// withDefaults replaces missing or unknown values so a bad setting cannot disable the gateway
+func (cfg Config) withDefaults() Config {
+	defaults := DefaultConfig()
+	if cfg.SendBufferSize <= 0 {
+		cfg.SendBufferSize = defaults.SendBufferSize
+	}
+	switch cfg.OverflowPolicy {
+	case DropOldest, DropNewest, Disconnect:
+	default:
+		cfg.OverflowPolicy = defaults.OverflowPolicy
+	}
+	if cfg.OverflowCloseCode == 0 {
+		cfg.OverflowCloseCode = defaults.OverflowCloseCode
+	}
+	if cfg.PongWait <= 0 {
+		cfg.PongWait = defaults.PongWait
+	}
+	// ✅ A ping must be able to arrive before the read deadline expires
+	if cfg.PingInterval <= 0 || cfg.PingInterval >= cfg.PongWait {
+		cfg.PingInterval = cfg.PongWait * 9 / 10
+	}
+	if cfg.WriteWait <= 0 {
+		cfg.WriteWait = defaults.WriteWait
+	}
+	if cfg.IdleTimeout <= 0 {
+		cfg.IdleTimeout = defaults.IdleTimeout
+	}
+	if cfg.SubscribeTimeout <= 0 {
+		cfg.SubscribeTimeout = defaults.SubscribeTimeout
+	}
+	return cfg
+}
@@ -0,0 +1,91 @@
+package websocket
+
+import (
+	"GoSyntaxDoc/presentation/middleware"
+	"encoding/json"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ✅ Frame types exchanged with clients
+const (
+	FrameSubscribe    = "subscribe"
+	FrameUnsubscribe  = "unsubscribe"
+	FrameSubscribed   = "subscribed"
+	FrameUnsubscribed = "unsubscribed"
+	FrameError        = "error"
+	FrameAck          = "ack"
+	FrameNack         = "nack"
+)
+
+// ✅ Error codes sent in NackFrame and ErrorFrame
+const (
+	ErrCodeInvalidJSON         = "invalid_json"
+	ErrCodeUnknownEvent        = "unknown_event"
+	ErrCodeInvalidPayload      = "invalid_payload"
+	ErrCodeInvalidSubscription = "invalid_subscription"
+	ErrCodeProduceFailed       = "produce_failed"
+	ErrCodeInternal            = "internal_error"
+)
+
+// ✅ AckFrame - Sent once Kafka has accepted a command
+type AckFrame struct {
+	Type      string `json:"type"` // Always FrameAck
+	RequestID string `json:"request_id,omitempty"`
+	Topic     string `json:"topic"`
+}
+
+// ✅ NackFrame - Sent when a command was not accepted; Retryable tells clients
+// whether resending the same frame can succeed
+type NackFrame struct {
+	Type      string `json:"type"` // Always FrameNack
+	RequestID string `json:"request_id,omitempty"`
+	Code      string `json:"code"`
+	Message   string `json:"message"`
+	Retryable bool   `json:"retryable"`
+}
+
+// ✅ ErrorFrame - Sent when the gateway rejects a control frame
+type ErrorFrame struct {
+	Type      string `json:"type"` // Always FrameError
+	RequestID string `json:"request_id,omitempty"`
+	Code      string `json:"code"`
+	Message   string `json:"message"`
+}
+
+// ✅ sendFrame: Marshals a frame and queues it for the client
+func sendFrame(client *Client, frame interface{}) {
+	data, err := json.Marshal(frame)
+	if err != nil {
+		middleware.Log.WithFields(logrus.Fields{"error": err}).Error("Error marshalling WebSocket frame")
+		return
+	}
+	client.Enqueue(data)
+}
+
+func sendError(client *Client, requestID string, code string, message string) {
+	sendFrame(client, ErrorFrame{
+		Type:      FrameError,
+		RequestID: requestID,
+		Code:      code,
+		Message:   message,
+	})
+}
+
+func sendAck(client *Client, requestID string, topic string) {
+	sendFrame(client, AckFrame{
+		Type:      FrameAck,
+		RequestID: requestID,
+		Topic:     topic,
+	})
+}
+
+func sendNack(client *Client, requestID string, code string, message string, retryable bool) {
+	sendFrame(client, NackFrame{
+		Type:      FrameNack,
+		RequestID: requestID,
+		Code:      code,
+		Message:   message,
+		Retryable: retryable,
+	})
+}
@@ -1,34 +1,59 @@
 package websocket
 
 import (
+	"GoSyntaxDoc/domain/entities"
 	"GoSyntaxDoc/infrastructure"
 	"GoSyntaxDoc/infrastructure/redis"
 	"GoSyntaxDoc/presentation/middleware"
 	"encoding/json"
+	"errors"
+	"strconv"
 	"sync"
+	"time"
 
 	"github.com/gofiber/contrib/websocket"
+	"github.com/google/uuid"
 	"github.com/sirupsen/logrus"
 )
 
+const RedisChannel = "users_actions"
+
+// ✅ CommandSchemaVersion: Version of WebsocketEvent as produced to Kafka, sent in the schema-version header
+const CommandSchemaVersion = 1
+
 type WebsocketEvent struct {
-	Type  string      `json:"type"`
-	Event string      `json:"event"`
-	Data  interface{} `json:"data"`
+	Type         string             `json:"type"`
+	Event        string             `json:"event"`
+	RequestID    string             `json:"request_id,omitempty"`
+	EventID      string             `json:"event_id,omitempty"`      // ✅ Always overwritten by the gateway
+	Channel      string             `json:"channel,omitempty"`       // ✅ Only used by subscribe/unsubscribe frames
+	ConnectionID string             `json:"connection_id,omitempty"` // ✅ Always overwritten by the gateway
+	Identity     *entities.Identity `json:"identity,omitempty"`      // ✅ Always overwritten by the gateway
+	Data         interface{}        `json:"data"`
 }
 
 type WebSocketManager struct {
-	Producer     *infrastructure.KafkaProducer
+	Producer     infrastructure.Producer
 	RedisService *redis.RedisService
-	clients      map[*websocket.Conn]bool
+	Events       *EventRegistry                // ✅ Allow-list of inbound events, DefaultEventRegistry unless replaced
+	Keys         *infrastructure.KeyStrategies // ✅ Partition keys per topic, DefaultKeyStrategies unless replaced
+	clients      map[string]*Client
+	subscribers  map[string]map[string]*Client   // channel → connection ID → client
+	redisSubs    map[string]*channelSubscription // channel → its Redis subscription, pending until ready
+	config       Config
 	mu           sync.Mutex
 }
 
-func NewWebSocketManager(producer *infrastructure.KafkaProducer, redisService *redis.RedisService) *WebSocketManager {
+func NewWebSocketManager(producer infrastructure.Producer, redisService *redis.RedisService, cfg Config) *WebSocketManager {
 	wsm := &WebSocketManager{
 		Producer:     producer,
 		RedisService: redisService,
-		clients:      make(map[*websocket.Conn]bool),
+		Events:       DefaultEventRegistry(),
+		Keys:         infrastructure.DefaultKeyStrategies(),
+		config:       cfg.withDefaults(),
+		clients:      make(map[string]*Client),
+		subscribers:  make(map[string]map[string]*Client),
+		redisSubs:    make(map[string]*channelSubscription),
 	}
 	go wsm.listenToRedis()
 	return wsm
@@ -37,58 +62,230 @@ func NewWebSocketManager(producer *infrastructure.KafkaProducer, redisService *r
 func (wsm *WebSocketManager) HandleWebsocket(c *websocket.Conn) {
 	defer c.Close()
 
+	identity, _ := c.Locals(IdentityLocal).(*entities.Identity)
+	client := NewClient(c, identity, wsm.config)
+	if client.Identity == nil {
+		// ✅ Routes must be registered behind RequireToken
+		message := websocket.FormatCloseMessage(websocket.ClosePolicyViolation, "unauthenticated")
+		_ = c.WriteControl(websocket.CloseMessage, message, time.Now().Add(wsm.config.WriteWait))
+		return
+	}
+
+	// ✅ Dead-peer detection: every pong or inbound frame pushes the read deadline forward
+	_ = c.SetReadDeadline(time.Now().Add(wsm.config.PongWait))
+	c.SetPongHandler(func(string) error {
+		return c.SetReadDeadline(time.Now().Add(wsm.config.PongWait))
+	})
+	client.Start()
+
 	wsm.mu.Lock()
-	wsm.clients[c] = true
+	wsm.clients[client.ID] = client
 	wsm.mu.Unlock()
-	logrus.Infof("✅ WebSocket client registered: %v", c.RemoteAddr())
+	middleware.Log.WithFields(logrus.Fields{
+		"connection_id": client.ID,
+		"remote_addr":   c.RemoteAddr().String(),
+		"subject":       client.Identity.Subject,
+		"tenant":        client.Identity.Tenant,
+	}).Info("✅ WebSocket client registered")
 
+	var readErr error
 	defer func() {
 		wsm.mu.Lock()
-		delete(wsm.clients, c)
+		delete(wsm.clients, client.ID)
 		wsm.mu.Unlock()
+		wsm.unsubscribeAll(client)
+
+		// ✅ Wait for the writer so the connection is not used after the handler returns
+		<-client.done
+		middleware.Log.WithFields(logrus.Fields{
+			"connection_id":   client.ID,
+			"remote_addr":     c.RemoteAddr().String(),
+			"reason":          client.reason,
+			"close_code":      client.closeCode,
+			"error":           readErr,
+			"duration":        time.Since(client.connectedAt).String(),
+			"max_queue_depth": client.MaxQueueDepth(),
+			"dropped":         client.Dropped(),
+		}).Info("WebSocket client disconnected")
 	}()
 	for {
 		_, message, err := c.ReadMessage()
 		if err != nil {
-			middleware.Log.WithFields(logrus.Fields{"error": err}).Error("Error reading message from WebSocket")
+			readErr = err
+			reason := readReason(err)
+			code := 0
+			if reason == ReasonPongTimeout {
+				code = websocket.CloseGoingAway
+			}
+			client.stop(code, reason) // ✅ No-op if the writer already stopped with its own reason
 			break
 		}
+		client.touch()
+		_ = c.SetReadDeadline(time.Now().Add(wsm.config.PongWait))
 
 		var event WebsocketEvent
 		err = json.Unmarshal(message, &event)
 		if err != nil {
 			middleware.Log.WithFields(logrus.Fields{"error": err}).Error("Error unmarshalling JSON message")
+			sendNack(client, "", ErrCodeInvalidJSON, "frame is not a valid JSON event", false)
+			continue
+		}
+
+		if event.Type == FrameSubscribe || event.Type == FrameUnsubscribe {
+			wsm.handleControlFrame(client, event)
 			continue
 		}
 
-		// ✅ Construct the Kafka topic dynamically
-		kafkaTopic := event.Event + "." + event.Type
+		// ✅ Only registered event/type pairs with a valid payload reach Kafka
+		spec, ok := wsm.Events.Lookup(event.Event, event.Type)
+		if !ok {
+			middleware.Log.WithFields(logrus.Fields{"event": event.Event, "type": event.Type, "connection_id": client.ID}).Warn("⚠️ Rejected unknown WebSocket event")
+			sendNack(client, event.RequestID, ErrCodeUnknownEvent, "unknown event "+event.Event+"."+event.Type, false)
+			continue
+		}
+		if event.Data == nil {
+			event.Data = map[string]interface{}{}
+		}
+		if err := spec.Schema.Validate(event.Data); err != nil {
+			middleware.Log.WithFields(logrus.Fields{"error": err, "topic": spec.Topic, "connection_id": client.ID}).Warn("⚠️ Rejected invalid WebSocket payload")
+			sendNack(client, event.RequestID, ErrCodeInvalidPayload, err.Error(), false)
+			continue
+		}
 
-		// ✅ Send to Kafka with correct topic
-		err = wsm.Producer.ProduceMessage(kafkaTopic, event.Event, string(message))
+		// ✅ Stamp the connection ID so the reply can be routed back to this client,
+		// and the verified identity so consumers know who issued the command
+		event.ConnectionID = client.ID
+		event.Identity = client.Identity
+		event.EventID = uuid.NewString() // ✅ Lets consumers recognise redeliveries
+		payload, err := json.Marshal(event)
 		if err != nil {
-			middleware.Log.WithFields(logrus.Fields{"error": err}).Error("Error producing Kafka message")
+			middleware.Log.WithFields(logrus.Fields{"error": err}).Error("Error marshalling Kafka message")
+			sendNack(client, event.RequestID, ErrCodeInternal, "could not encode command", false)
 			continue
 		}
-		middleware.Log.WithFields(logrus.Fields{"topic": kafkaTopic}).Info("✅ WebSocket message forwarded to Kafka")
+
+		kafkaTopic := spec.Topic
+		key, err := wsm.Keys.Key(kafkaTopic, infrastructure.KeySource{
+			Value:        payload,
+			ConnectionID: client.ID,
+			Tenant:       client.Identity.Tenant,
+		})
+		if err != nil {
+			// ✅ Still deliverable, just without per-key ordering
+			middleware.Log.WithFields(logrus.Fields{"error": err, "topic": kafkaTopic}).Warn("⚠️ Could not derive partition key")
+		}
+
+		// ✅ Send to Kafka without waiting; ack/nack follow once the broker answers
+		requestID := event.RequestID
+		msg := infrastructure.Message{
+			Topic:   kafkaTopic,
+			Key:     key,
+			Value:   payload,
+			Headers: commandHeaders(client, requestID, event.EventID),
+		}
+		wsm.Producer.ProduceAsync(msg, func(report infrastructure.DeliveryReport) {
+			if report.Err != nil {
+				middleware.Log.WithFields(logrus.Fields{"error": report.Err}).Error("Error producing Kafka message")
+				sendNack(client, requestID, ErrCodeProduceFailed, "command could not be delivered, retry later", true)
+				return
+			}
+			sendAck(client, requestID, kafkaTopic)
+			middleware.Log.WithFields(logrus.Fields{
+				"topic":         kafkaTopic,
+				"connection_id": client.ID,
+				"request_id":    requestID,
+				"event_id":      msg.Headers.Get(infrastructure.HeaderEventID),
+				"traceparent":   msg.Headers.Get(infrastructure.HeaderTraceparent),
+			}).Info("✅ WebSocket message forwarded to Kafka")
+		})
 	}
 }
 
+// ✅ handleControlFrame: Applies a subscribe/unsubscribe request and confirms it to the client
+func (wsm *WebSocketManager) handleControlFrame(client *Client, event WebsocketEvent) {
+	response := SubscriptionFrame{
+		Type:      FrameUnsubscribed,
+		Channel:   event.Channel,
+		RequestID: event.RequestID,
+	}
+
+	if event.Type == FrameSubscribe {
+		if err := wsm.subscribe(client, event.Channel); err != nil {
+			sendError(client, event.RequestID, ErrCodeInvalidSubscription, err.Error())
+			return
+		}
+		response.Type = FrameSubscribed
+	} else {
+		wsm.unsubscribe(client, event.Channel)
+	}
+
+	sendFrame(client, response)
+}
+
+// ✅ commandHeaders: Standard Kafka headers for a client command
+// The correlation ID is the client's request ID, or the event ID when none was given.
+func commandHeaders(client *Client, requestID string, eventID string) infrastructure.Headers {
+	correlationID := requestID
+	if correlationID == "" {
+		correlationID = eventID
+	}
+
+	headers := infrastructure.Headers{
+		infrastructure.HeaderEventID:       eventID,
+		infrastructure.HeaderCorrelationID: correlationID,
+		infrastructure.HeaderSchemaVersion: strconv.Itoa(CommandSchemaVersion),
+		infrastructure.HeaderContentType:   infrastructure.ContentTypeJSON,
+		infrastructure.HeaderConnectionID:  client.ID,
+		infrastructure.HeaderUserSubject:   client.Identity.Subject,
+		infrastructure.HeaderTraceparent:   infrastructure.NewTraceparent(),
+	}
+	if requestID != "" {
+		headers[infrastructure.HeaderRequestID] = requestID
+	}
+	if client.Identity.Tenant != "" {
+		headers[infrastructure.HeaderUserTenant] = client.Identity.Tenant
+	}
+	return headers
+}
+
 func (wsm *WebSocketManager) listenToRedis() {
-	wsm.RedisService.Subscribe("users_actions", func(msg string) {
+	wsm.RedisService.Subscribe(RedisChannel, func(msg string) {
 		logrus.Infof("✅ Received Redis message: %s", msg) // ✅ Debug log
 
+		message, err := decodeRedisMessage(msg)
+		if err != nil || message.ConnectionID == "" {
+			logrus.WithFields(logrus.Fields{"error": err}).Warn("⚠️ Dropping Redis message without a connection ID")
+			return
+		}
+
+		// ✅ Replies for connections held by another gateway instance are ignored
 		wsm.mu.Lock()
-		defer wsm.mu.Unlock()
-
-		for client := range wsm.clients {
-			err := client.WriteMessage(websocket.TextMessage, []byte(msg))
-			if err != nil {
-				logrus.WithFields(logrus.Fields{"error": err}).Error("❌ Error writing message to WebSocket")
-				delete(wsm.clients, client) // Remove disconnected clients
-			} else {
-				logrus.Infof("✅ Message sent to WebSocket client: %v", client.RemoteAddr())
-			}
+		client, ok := wsm.clients[message.ConnectionID]
+		wsm.mu.Unlock()
+		if !ok {
+			return
 		}
+
+		client.Enqueue(message.Envelope)
+		logrus.Infof("✅ Message queued for WebSocket client: %s (queue_depth=%d)", client.ID, client.QueueDepth())
 	})
 }
+
+// rawRedisMessage mirrors events.RedisMessage but keeps the envelope as sent,
+// so the client receives exactly what the consumer produced minus the routing ID
+type rawRedisMessage struct {
+	ConnectionID string          `json:"connection_id"`
+	Tenant       string          `json:"tenant"`
+	Envelope     json.RawMessage `json:"envelope"`
+}
+
+func decodeRedisMessage(msg string) (rawRedisMessage, error) {
+	var message rawRedisMessage
+	if err := json.Unmarshal([]byte(msg), &message); err != nil {
+		return message, err
+	}
+	if len(message.Envelope) == 0 {
+		return message, errors.New("redis message has no envelope")
+	}
+	return message, nil
+}
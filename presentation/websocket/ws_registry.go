@@ -0,0 +1,52 @@
+package websocket
+
+import (
+	"GoSyntaxDoc/domain/topics"
+	"GoSyntaxDoc/infrastructure/schema"
+	"sync"
+)
+
+// ✅ EventSpec - An inbound event/type pair the gateway accepts, and where it goes
+type EventSpec struct {
+	Event  string
+	Type   string
+	Topic  string         // Kafka topic the command is produced to
+	Schema *schema.Schema // Validates the frame's "data"
+}
+
+// ✅ EventRegistry - Allow-list of inbound events; anything not registered is rejected
+type EventRegistry struct {
+	mu    sync.RWMutex
+	specs map[string]EventSpec
+}
+
+func NewEventRegistry() *EventRegistry {
+	return &EventRegistry{specs: make(map[string]EventSpec)}
+}
+
+func (r *EventRegistry) Register(spec EventSpec) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.specs[spec.Event+"."+spec.Type] = spec
+}
+
+func (r *EventRegistry) Lookup(event string, eventType string) (EventSpec, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	spec, ok := r.specs[event+"."+eventType]
+	return spec, ok
+}
+
+// ✅ DefaultEventRegistry: The commands the gateway produces, as declared in the topic catalog
+func DefaultEventRegistry() *EventRegistry {
+	registry := NewEventRegistry()
+	for _, topic := range topics.ProducedBy(topics.ServiceGateway) {
+		registry.Register(EventSpec{
+			Event:  topic.Event,
+			Type:   topic.Type,
+			Topic:  topic.Name,
+			Schema: schema.MustCompile(topic.Schema),
+		})
+	}
+	return registry
+}
@@ -1,10 +1,54 @@
 package websocket
 
 import (
+	"GoSyntaxDoc/infrastructure/auth"
+	"GoSyntaxDoc/presentation/middleware"
+	"strings"
+
 	"github.com/gofiber/contrib/websocket"
 	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
 )
 
-func RegisterWebsocketRoutes(app *fiber.App, manager *WebSocketManager) {
-	app.Get("/ws", websocket.New(manager.HandleWebsocket))
+// IdentityLocal is the Locals key under which the authenticated identity is stored
+const IdentityLocal = "identity"
+
+func RegisterWebsocketRoutes(app *fiber.App, manager *WebSocketManager, verifier *auth.JWTVerifier) {
+	app.Get("/ws", RequireToken(verifier), websocket.New(manager.HandleWebsocket))
+}
+
+// ✅ RequireToken: Rejects the upgrade unless it carries a valid bearer token
+// The token is read from the Authorization header, or from the "token" query
+// parameter for browsers that cannot set headers on WebSocket requests.
+func RequireToken(verifier *auth.JWTVerifier) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if !websocket.IsWebSocketUpgrade(c) {
+			return fiber.ErrUpgradeRequired
+		}
+
+		token := bearerToken(c.Get(fiber.HeaderAuthorization))
+		if token == "" {
+			token = c.Query("token")
+		}
+		if token == "" {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "missing token"})
+		}
+
+		identity, err := verifier.Verify(token)
+		if err != nil {
+			middleware.Log.WithFields(logrus.Fields{"error": err, "ip": c.IP()}).Warn("⚠️ Rejected WebSocket handshake")
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "invalid token"})
+		}
+
+		c.Locals(IdentityLocal, identity)
+		return c.Next()
+	}
+}
+
+func bearerToken(header string) string {
+	scheme, token, ok := strings.Cut(header, " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return ""
+	}
+	return strings.TrimSpace(token)
 }
@@ -0,0 +1,200 @@
+package websocket
+
+import (
+	"GoSyntaxDoc/infrastructure/redis"
+	"context"
+	"fmt"
+	"regexp"
+
+	"github.com/sirupsen/logrus"
+)
+
+const maxSubscriptionsPerClient = 32
+
+// Channel names such as "user.created" or "order.*"
+var channelPattern = regexp.MustCompile(`^[a-z0-9_-]+(\.[a-z0-9_-]+)*(\.\*)?$`)
+
+// ✅ SubscriptionFrame - Confirmation sent back for subscribe/unsubscribe control frames
+type SubscriptionFrame struct {
+	Type      string `json:"type"`
+	Channel   string `json:"channel"`
+	RequestID string `json:"request_id,omitempty"`
+}
+
+func validateChannel(channel string) error {
+	if channel == RedisChannel || !channelPattern.MatchString(channel) {
+		return fmt.Errorf("invalid channel %q", channel)
+	}
+	return nil
+}
+
+// ✅ channelSubscription - The Redis side of a channel in the index
+// Subscribing and closing talk to Redis, so they run outside wsm.mu: the index is
+// updated under the lock and the subscription is pending until ready is closed.
+type channelSubscription struct {
+	ready chan struct{}       // Closed once Redis confirmed the subscription or it failed
+	sub   *redis.Subscription // Set under wsm.mu before ready is closed
+	err   error               // Why subscribing failed; set before ready is closed
+}
+
+// ✅ subscribe: Adds the client to the channel index, subscribing to Redis on first use
+// Returns once Redis has confirmed the channel's subscription, so the client is
+// not told it is subscribed before messages can arrive. If Redis does not confirm
+// within SubscribeTimeout, the channel is taken out of the index for every client
+// waiting on it and the error is returned.
+func (wsm *WebSocketManager) subscribe(client *Client, channel string) error {
+	if err := validateChannel(channel); err != nil {
+		return err
+	}
+
+	wsm.mu.Lock()
+	if client.channels[channel] {
+		state := wsm.redisSubs[channel]
+		wsm.mu.Unlock()
+		<-state.ready
+		return state.err
+	}
+	if len(client.channels) >= maxSubscriptionsPerClient {
+		wsm.mu.Unlock()
+		return fmt.Errorf("subscription limit of %d reached", maxSubscriptionsPerClient)
+	}
+
+	subscribers, ok := wsm.subscribers[channel]
+	if !ok {
+		subscribers = make(map[string]*Client)
+		wsm.subscribers[channel] = subscribers
+		wsm.redisSubs[channel] = &channelSubscription{ready: make(chan struct{})}
+	}
+	subscribers[client.ID] = client
+	client.channels[channel] = true
+	state := wsm.redisSubs[channel]
+	wsm.mu.Unlock()
+
+	if ok {
+		<-state.ready // ✅ Another client is subscribing to Redis for this channel
+		return state.err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), wsm.config.SubscribeTimeout)
+	defer cancel()
+	sub, err := wsm.RedisService.SubscribeConfirmed(ctx, channel, func(msg string) {
+		wsm.deliverToChannel(state, channel, msg)
+	})
+
+	wsm.mu.Lock()
+	current := wsm.redisSubs[channel] == state
+	if err != nil {
+		state.err = fmt.Errorf("subscribing to %q failed", channel)
+		if current {
+			for _, subscriber := range wsm.subscribers[channel] {
+				delete(subscriber.channels, channel)
+			}
+			delete(wsm.subscribers, channel)
+			delete(wsm.redisSubs, channel)
+		}
+	} else {
+		state.sub = sub
+	}
+	wsm.mu.Unlock()
+	close(state.ready)
+
+	if err != nil {
+		logrus.WithFields(logrus.Fields{"error": err, "channel": channel}).Error("❌ Error subscribing to Redis channel")
+		return state.err
+	}
+	if !current {
+		// ✅ Every subscriber left while Redis was being subscribed
+		closeSubscription(channel, sub)
+		return nil
+	}
+	logrus.Infof("✅ Subscribed to Redis channel: %s", channel)
+	return nil
+}
+
+// ✅ unsubscribe: Removes the client from the channel index, dropping the Redis subscription when unused
+func (wsm *WebSocketManager) unsubscribe(client *Client, channel string) {
+	wsm.mu.Lock()
+	sub := wsm.unsubscribeLocked(client, channel)
+	wsm.mu.Unlock()
+
+	if sub != nil {
+		closeSubscription(channel, sub)
+	}
+}
+
+// unsubscribeLocked returns the Redis subscription the caller must close once it
+// has released wsm.mu; nil if the channel is still used or its subscription is
+// pending, in which case subscribe closes it when it becomes ready
+func (wsm *WebSocketManager) unsubscribeLocked(client *Client, channel string) *redis.Subscription {
+	delete(client.channels, channel)
+
+	subscribers, ok := wsm.subscribers[channel]
+	if !ok {
+		return nil
+	}
+	delete(subscribers, client.ID)
+	if len(subscribers) > 0 {
+		return nil
+	}
+
+	delete(wsm.subscribers, channel)
+	state, ok := wsm.redisSubs[channel]
+	if !ok {
+		return nil
+	}
+	delete(wsm.redisSubs, channel)
+	return state.sub
+}
+
+// ✅ unsubscribeAll: Called when a connection goes away
+func (wsm *WebSocketManager) unsubscribeAll(client *Client) {
+	unused := make(map[string]*redis.Subscription)
+	wsm.mu.Lock()
+	for channel := range client.channels {
+		if sub := wsm.unsubscribeLocked(client, channel); sub != nil {
+			unused[channel] = sub
+		}
+	}
+	wsm.mu.Unlock()
+
+	for channel, sub := range unused {
+		closeSubscription(channel, sub)
+	}
+}
+
+// deliverToChannel fans a message out to the channel's subscribers in its tenant;
+// messages from a subscription that is no longer the channel's current one, and is
+// about to be closed, are dropped so a quick resubscribe doesn't deliver them twice
+func (wsm *WebSocketManager) deliverToChannel(state *channelSubscription, channel string, msg string) {
+	message, err := decodeRedisMessage(msg)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{"error": err, "channel": channel}).Warn("⚠️ Dropping malformed channel message")
+		return
+	}
+
+	wsm.mu.Lock()
+	if wsm.redisSubs[channel] != state {
+		wsm.mu.Unlock()
+		return
+	}
+	targets := make([]*Client, 0, len(wsm.subscribers[channel]))
+	for _, client := range wsm.subscribers[channel] {
+		// ✅ Tenants only see their own events; an event without a tenant goes to clients without one
+		if client.Identity.Tenant == message.Tenant {
+			targets = append(targets, client)
+		}
+	}
+	wsm.mu.Unlock()
+
+	for _, client := range targets {
+		client.Enqueue(message.Envelope)
+	}
+}
+
+func closeSubscription(channel string, sub *redis.Subscription) {
+	if err := sub.Close(); err != nil {
+		logrus.WithFields(logrus.Fields{"error": err, "channel": channel}).Warn("⚠️ Error closing Redis subscription")
+		return
+	}
+	logrus.Infof("✅ Unsubscribed from Redis channel: %s", channel)
+}
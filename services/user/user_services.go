@@ -2,46 +2,88 @@ package user
 
 import (
 	"GoSyntaxDoc/domain/entities"
+	"GoSyntaxDoc/domain/events"
+	"GoSyntaxDoc/domain/topics"
+	"GoSyntaxDoc/infrastructure"
+	"GoSyntaxDoc/infrastructure/outbox"
 	"GoSyntaxDoc/infrastructure/repositories"
+	"encoding/json"
+	"errors"
 	"fmt"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/sirupsen/logrus"
 )
 
+var (
+	ErrInvalidUserID   = errors.New("invalid user ID")
+	ErrUserNotFound    = errors.New("user not found")
+	ErrInvalidUserData = errors.New("first name and last name are required")
+)
+
+// ✅ UserStore - The persistence UserService needs; *repositories.UserRepository in production
+type UserStore interface {
+	FetchUserById(userID int) (*entities.User, error)
+	FetchAllUsers() ([]entities.User, error)
+	// CreateUser inserts the user and the outbox message newEvent builds for it in
+	// one transaction, unless eventID was already applied; then it returns the
+	// user created that time with created set to false
+	CreateUser(eventID string, firstName string, lastName string, newEvent func(*entities.User) (outbox.Message, error)) (*entities.User, bool, error)
+}
+
+var _ UserStore = (*repositories.UserRepository)(nil)
+
 type UserService struct {
-	Repo *repositories.UserRepository
+	Repo UserStore
+	Keys *infrastructure.KeyStrategies // ✅ Partition keys of the events it records, DefaultKeyStrategies unless replaced
 }
 
-func NewUserService(repo *repositories.UserRepository) *UserService {
-	return &UserService{Repo: repo}
+func NewUserService(repo UserStore) *UserService {
+	return &UserService{Repo: repo, Keys: infrastructure.DefaultKeyStrategies()}
 }
 
 func (s *UserService) FetchUserById(userID int) (*entities.User, error) {
 	if userID <= 0 {
-		return nil, fmt.Errorf("invalid user ID")
+		return nil, ErrInvalidUserID
 	}
 
 	user, err := s.Repo.FetchUserById(userID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrUserNotFound
+	}
 	if err != nil {
-		return nil, fmt.Errorf("user not found")
+		return nil, fmt.Errorf("fetch user %d: %w", userID, err)
 	}
 
 	return user, nil // ✅ Return the user object instead of an HTTP response
 }
 
-func (s *UserService) HandleUserCreated(firstName string, lastName string) (*entities.User, error) {
+// ✅ HandleUserCreated: Creates the user and records "user.created" in the outbox
+// origin are the headers of the command that asked for it, used to route the reply.
+// A command whose event-id was already applied returns the user created then, with
+// created set to false, and records nothing new.
+func (s *UserService) HandleUserCreated(firstName string, lastName string, origin infrastructure.Headers) (*entities.User, bool, error) {
 	if firstName == "" || lastName == "" {
 		logrus.WithFields(logrus.Fields{"error": "Invalid user data"}).Error("Invalid user data")
-		return nil, fmt.Errorf("first name and last name are required")
+		return nil, false, ErrInvalidUserData
 	}
 
-	user, err := s.Repo.CreateUser(firstName, lastName)
+	eventID := origin.Get(infrastructure.HeaderEventID)
+	user, created, err := s.Repo.CreateUser(eventID, firstName, lastName, func(user *entities.User) (outbox.Message, error) {
+		envelope := events.NewEnvelope("user", "created", origin.Get(infrastructure.HeaderCorrelationID), user)
+		key, err := s.eventKey(topics.UserEvents, envelope, origin)
+		if err != nil {
+			// ✅ Still deliverable, just without per-key ordering
+			logrus.WithFields(logrus.Fields{"error": err, "topic": topics.UserEvents}).Warn("⚠️ Could not derive partition key")
+		}
+		return outbox.NewMessage(topics.UserEvents, key, envelope, origin)
+	})
 	if err != nil {
 		logrus.WithFields(logrus.Fields{"error": err}).Error("Failed to create user")
-		return nil, err
+		return nil, false, err
 	}
 
-	return user, nil // ✅ Correctly returning both user and error
+	return user, created, nil // ✅ Correctly returning both user and error
 }
 
 func (s *UserService) HandleUserRead() ([]entities.User, error) {
@@ -52,3 +94,16 @@ func (s *UserService) HandleUserRead() ([]entities.User, error) {
 	}
 	return users, nil
 }
+
+// eventKey: The partition key the topic's strategy derives for an event
+func (s *UserService) eventKey(topic string, envelope events.Envelope, origin infrastructure.Headers) (string, error) {
+	value, err := json.Marshal(envelope)
+	if err != nil {
+		return "", err
+	}
+	return s.Keys.Key(topic, infrastructure.KeySource{
+		Value:        value,
+		ConnectionID: origin.Get(infrastructure.HeaderConnectionID),
+		Tenant:       origin.Get(infrastructure.HeaderUserTenant),
+	})
+}
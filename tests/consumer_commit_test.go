@@ -0,0 +1,181 @@
+package websocket_test
+
+import (
+	"context"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"GoSyntaxDoc/infrastructure"
+	"GoSyntaxDoc/infrastructure/consumers"
+)
+
+// ✅ fakePartition - One partition with a committed offset, shared by the readers of a "group"
+type fakePartition struct {
+	mu        sync.Mutex
+	messages  []kafka.Message
+	committed int64
+}
+
+func newFakePartition(topic string, values ...string) *fakePartition {
+	p := &fakePartition{}
+	for i, value := range values {
+		p.messages = append(p.messages, kafka.Message{Topic: topic, Offset: int64(i), Value: []byte(value)})
+	}
+	return p
+}
+
+func (p *fakePartition) Committed() int64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.committed
+}
+
+// ✅ reader starts at the committed offset, as a group member taking over the partition would
+func (p *fakePartition) reader() *fakeReader {
+	return &fakeReader{partition: p, next: p.Committed(), closed: make(chan struct{})}
+}
+
+type fakeReader struct {
+	partition *fakePartition
+	next      int64
+	closeOnce sync.Once
+	closed    chan struct{}
+}
+
+func (r *fakeReader) FetchMessage(ctx context.Context) (kafka.Message, error) {
+	r.partition.mu.Lock()
+	if r.next < int64(len(r.partition.messages)) {
+		msg := r.partition.messages[r.next]
+		r.next++
+		r.partition.mu.Unlock()
+		return msg, nil
+	}
+	r.partition.mu.Unlock()
+
+	select {
+	case <-r.closed:
+		return kafka.Message{}, io.EOF
+	case <-ctx.Done():
+		return kafka.Message{}, ctx.Err()
+	}
+}
+
+func (r *fakeReader) CommitMessages(ctx context.Context, msgs ...kafka.Message) error {
+	r.partition.mu.Lock()
+	defer r.partition.mu.Unlock()
+	for _, msg := range msgs {
+		if msg.Offset+1 > r.partition.committed {
+			r.partition.committed = msg.Offset + 1
+		}
+	}
+	return nil
+}
+
+func (r *fakeReader) Close() error {
+	r.closeOnce.Do(func() { close(r.closed) })
+	return nil
+}
+
+func newTestConsumer(reader consumers.MessageReader, router *consumers.Router, producer infrastructure.Producer) *consumers.KafkaConsumer {
+	return &consumers.KafkaConsumer{
+		Reader:      reader,
+		Router:      router,
+		Retries:     consumers.NewRetryQueue(producer, nil),
+		DeadLetters: consumers.NewDeadLetterQueue(producer),
+	}
+}
+
+// ✅ runConsumer runs ConsumeMessages until its reader is closed and reports when it returned
+func runConsumer(consumer *consumers.KafkaConsumer) <-chan struct{} {
+	return runConsumerContext(context.Background(), consumer)
+}
+
+func runConsumerContext(ctx context.Context, consumer *consumers.KafkaConsumer) <-chan struct{} {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		consumer.ConsumeMessages(ctx)
+	}()
+	return done
+}
+
+func waitFor(t *testing.T, done <-chan struct{}) {
+	t.Helper()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out")
+	}
+}
+
+func TestCrashMidHandlerRedeliversMessage(t *testing.T) {
+	partition := newFakePartition("user.read", `{"event": "user", "type": "read"}`)
+
+	var mu sync.Mutex
+	calls := 0
+	entered := make(chan struct{})
+	crash := make(chan struct{})
+	router := consumers.NewRouter()
+	router.Register("user.read", func(ctx context.Context, msg consumers.Message) error {
+		mu.Lock()
+		calls++
+		first := calls == 1
+		mu.Unlock()
+		if first {
+			close(entered)
+			<-crash // ✅ The member dies here; to the broker it is simply gone
+		}
+		return nil
+	})
+	producer := infrastructure.NewMemoryProducer()
+
+	// ✅ First member crashes mid-handler: nothing is committed
+	crashed := partition.reader()
+	crashedDone := runConsumer(newTestConsumer(crashed, router, producer))
+	waitFor(t, entered)
+	assert.Equal(t, int64(0), partition.Committed())
+
+	// ✅ The member that takes over gets the same message and commits it once handled
+	takeover := partition.reader()
+	done := runConsumer(newTestConsumer(takeover, router, producer))
+	require.Eventually(t, func() bool { return partition.Committed() == 1 }, 5*time.Second, 10*time.Millisecond)
+	require.NoError(t, takeover.Close())
+	waitFor(t, done)
+
+	mu.Lock()
+	assert.Equal(t, 2, calls)
+	mu.Unlock()
+	assert.Empty(t, producer.Messages())
+
+	require.NoError(t, crashed.Close())
+	close(crash)
+	waitFor(t, crashedDone)
+}
+
+func TestPoisonMessageIsDeadLetteredThenCommitted(t *testing.T) {
+	partition := newFakePartition("user.fetch", `{not json`, `{"data": {"user_id": 1}}`)
+
+	handled := 0
+	router := consumers.NewRouter()
+	router.Register("user.fetch", consumers.Typed(func(ctx context.Context, msg consumers.Message, event map[string]interface{}) error {
+		handled++
+		return nil
+	}))
+	producer := infrastructure.NewMemoryProducer()
+
+	reader := partition.reader()
+	done := runConsumer(newTestConsumer(reader, router, producer))
+	require.Eventually(t, func() bool { return partition.Committed() == 2 }, 5*time.Second, 10*time.Millisecond)
+	require.NoError(t, reader.Close())
+	waitFor(t, done)
+
+	assert.Equal(t, 1, handled)
+	require.Len(t, producer.Messages(), 1)
+	assert.Equal(t, "user.fetch.dlq", producer.Messages()[0].Topic)
+}
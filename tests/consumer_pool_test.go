@@ -0,0 +1,92 @@
+package websocket_test
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"GoSyntaxDoc/infrastructure"
+	"GoSyntaxDoc/infrastructure/consumers"
+)
+
+func TestOffsetTrackerCommitsContiguousPrefix(t *testing.T) {
+	tracker := consumers.NewOffsetTracker()
+	msg := func(partition int, offset int64) kafka.Message {
+		return kafka.Message{Topic: "t", Partition: partition, Offset: offset}
+	}
+	for _, offset := range []int64{10, 11, 13} { // ✅ Offsets may have gaps
+		tracker.Start(msg(0, offset))
+	}
+	tracker.Start(msg(1, 5))
+
+	assert.Equal(t, 0, tracker.Done(msg(0, 11)))
+	assert.Equal(t, 0, tracker.Done(msg(0, 13)))
+	assert.Empty(t, tracker.Commits(), "offset 10 is still running")
+
+	assert.Equal(t, 3, tracker.Done(msg(0, 10)))
+	assert.Equal(t, 1, tracker.Done(msg(1, 5)))
+	assert.Equal(t, []kafka.Message{msg(0, 13), msg(1, 5)}, tracker.Commits())
+	assert.Empty(t, tracker.Commits(), "nothing new since the last call")
+}
+
+func TestWorkerPoolKeepsPerKeyOrder(t *testing.T) {
+	partition := &fakePartition{}
+	keys := []string{"a", "b", "c", "d"}
+	const perKey = 25
+	for i := 0; i < perKey; i++ {
+		for _, key := range keys {
+			partition.messages = append(partition.messages, kafka.Message{
+				Topic:  "user.fetch",
+				Offset: int64(len(partition.messages)),
+				Key:    []byte(key),
+				Value:  []byte(strconv.Itoa(i)),
+			})
+		}
+	}
+
+	var mu sync.Mutex
+	seen := make(map[string][]int)
+	release := make(chan struct{})
+	router := consumers.NewRouter()
+	router.Register("user.fetch", func(ctx context.Context, msg consumers.Message) error {
+		if string(msg.Key) == "a" && string(msg.Value) == "0" {
+			<-release // ✅ A slow first message for "a" must not hold up the other keys
+		}
+		n, _ := strconv.Atoi(string(msg.Value))
+		mu.Lock()
+		seen[string(msg.Key)] = append(seen[string(msg.Key)], n)
+		mu.Unlock()
+		return nil
+	})
+
+	reader := partition.reader()
+	consumer := newTestConsumer(reader, router, infrastructure.NewMemoryProducer())
+	consumer.Workers = 4
+	consumer.MaxInFlight = len(partition.messages)
+	done := runConsumer(consumer)
+
+	require.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(seen["b"]) == perKey && len(seen["c"]) == perKey && len(seen["d"]) == perKey
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, int64(0), partition.Committed(), "offset 0 is still running")
+
+	close(release)
+	require.Eventually(t, func() bool { return partition.Committed() == int64(len(partition.messages)) }, 5*time.Second, 10*time.Millisecond)
+	require.NoError(t, reader.Close())
+	waitFor(t, done)
+
+	for _, key := range keys {
+		for i, n := range seen[key] {
+			require.Equal(t, i, n, fmt.Sprintf("key %s handled out of order", key))
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package websocket_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"GoSyntaxDoc/domain/events"
+	"GoSyntaxDoc/infrastructure"
+	"GoSyntaxDoc/infrastructure/consumers"
+)
+
+func TestRouterDispatchesTypedEvents(t *testing.T) {
+	router := consumers.NewRouter()
+	var got events.KafkaUserFetchByIdEvent
+	router.Register("user.fetch", consumers.Typed(func(ctx context.Context, msg consumers.Message, event events.KafkaUserFetchByIdEvent) error {
+		got = event
+		return nil
+	}))
+	router.Register("user.read", func(context.Context, consumers.Message) error { return nil })
+
+	assert.Equal(t, []string{"user.fetch", "user.read"}, router.Topics())
+
+	err := router.Dispatch(context.Background(), consumers.Message{
+		Topic: "user.fetch",
+		Value: []byte(`{"event": "user", "type": "fetch", "connection_id": "c-1", "data": {"user_id": 7}}`),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 7, got.Data.UserID)
+	assert.Equal(t, "c-1", got.ConnectionID)
+
+	// ✅ Bodies that don't match the event type surface as *DecodeError
+	err = router.Dispatch(context.Background(), consumers.Message{Topic: "user.fetch", Value: []byte(`{"data": "x"}`)})
+	var decodeErr *consumers.DecodeError
+	assert.ErrorAs(t, err, &decodeErr)
+
+	err = router.Dispatch(context.Background(), consumers.Message{Topic: "order.created"})
+	assert.ErrorIs(t, err, consumers.ErrNoHandler)
+}
+
+func TestRouterMiddlewareOrder(t *testing.T) {
+	var calls []string
+	trace := func(name string) consumers.Middleware {
+		return func(next consumers.HandlerFunc) consumers.HandlerFunc {
+			return func(ctx context.Context, msg consumers.Message) error {
+				calls = append(calls, name)
+				return next(ctx, msg)
+			}
+		}
+	}
+
+	router := consumers.NewRouter()
+	router.Use(trace("outer"))
+	router.Register("t", func(context.Context, consumers.Message) error {
+		calls = append(calls, "handler")
+		return nil
+	}, trace("route"))
+	router.Use(trace("inner")) // ✅ Applies to handlers registered earlier too
+
+	require.NoError(t, router.Dispatch(context.Background(), consumers.Message{Topic: "t"}))
+	assert.Equal(t, []string{"outer", "inner", "route", "handler"}, calls)
+}
+
+func TestRecoveryAndMetrics(t *testing.T) {
+	metrics := consumers.NewMetrics()
+	router := consumers.NewRouter()
+	router.Use(consumers.WithMetrics(metrics), consumers.Recovery())
+	router.Register("t", func(context.Context, consumers.Message) error { panic("boom") })
+
+	err := router.Dispatch(context.Background(), consumers.Message{Topic: "t"})
+	var panicErr *consumers.PanicError
+	require.ErrorAs(t, err, &panicErr)
+	assert.Equal(t, "boom", panicErr.Value)
+	assert.Equal(t, int64(1), metrics.Snapshot()["t"].Failed)
+}
+
+func TestDedupeSkipsHandledEvents(t *testing.T) {
+	calls := 0
+	fail := true
+	router := consumers.NewRouter()
+	router.Use(consumers.Dedupe(consumers.NewDedupeCache(1)))
+	router.Register("t", func(context.Context, consumers.Message) error {
+		calls++
+		if fail {
+			return errors.New("transient")
+		}
+		return nil
+	})
+
+	msg := func(id string) consumers.Message {
+		return consumers.Message{Topic: "t", Headers: infrastructure.Headers{infrastructure.HeaderEventID: id}}
+	}
+
+	// ✅ Failed attempts don't count as handled
+	assert.Error(t, router.Dispatch(context.Background(), msg("e-1")))
+	fail = false
+	assert.NoError(t, router.Dispatch(context.Background(), msg("e-1")))
+	assert.NoError(t, router.Dispatch(context.Background(), msg("e-1")))
+	assert.Equal(t, 2, calls)
+
+	// ✅ The cache is bounded: e-2 evicts e-1
+	assert.NoError(t, router.Dispatch(context.Background(), msg("e-2")))
+	assert.NoError(t, router.Dispatch(context.Background(), msg("e-1")))
+	assert.Equal(t, 4, calls)
+}
@@ -0,0 +1,107 @@
+package websocket_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"GoSyntaxDoc/infrastructure"
+	"GoSyntaxDoc/infrastructure/consumers"
+)
+
+func TestShutdownFinishesInFlightHandlersAndCommits(t *testing.T) {
+	partition := newFakePartition("user.read", `{}`, `{}`)
+
+	entered := make(chan struct{}, 2)
+	release := make(chan struct{})
+	router := consumers.NewRouter()
+	router.Register("user.read", func(ctx context.Context, msg consumers.Message) error {
+		entered <- struct{}{}
+		<-release
+		return ctx.Err() // ✅ Handlers keep a live context while shutdown waits for them
+	})
+
+	consumer := newTestConsumer(partition.reader(), router, infrastructure.NewMemoryProducer())
+	consumer.ShutdownTimeout = 5 * time.Second
+	done := runConsumerContext(context.Background(), consumer)
+	waitFor(t, entered)
+
+	// ✅ Close cancels the consumer and returns only after the handler finished and was committed
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		assert.NoError(t, consumer.Close())
+	}()
+	select {
+	case <-closed:
+		t.Fatal("Close returned while a handler was running")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+	waitFor(t, closed)
+	waitFor(t, done)
+	assert.Equal(t, int64(1), partition.Committed(), "the second message was never started")
+}
+
+func TestShutdownDeadlineAbandonsStuckHandlers(t *testing.T) {
+	partition := newFakePartition("user.read", `{}`)
+
+	entered := make(chan struct{})
+	router := consumers.NewRouter()
+	router.Register("user.read", func(ctx context.Context, msg consumers.Message) error {
+		close(entered)
+		<-ctx.Done() // ✅ Cancelled once the deadline passes
+		return ctx.Err()
+	})
+	producer := infrastructure.NewMemoryProducer()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	consumer := newTestConsumer(partition.reader(), router, producer)
+	consumer.ShutdownTimeout = 50 * time.Millisecond
+	done := runConsumerContext(ctx, consumer)
+	waitFor(t, entered)
+
+	cancel()
+	waitFor(t, done)
+	require.NoError(t, consumer.Close())
+	assert.Equal(t, int64(0), partition.Committed(), "an unfinished message must be redelivered")
+}
+
+func TestShutdownWithAFullWindowReturns(t *testing.T) {
+	for _, timeout := range []time.Duration{50 * time.Millisecond, 0} {
+		partition := newFakePartition("user.read", `{}`, `{}`, `{}`)
+
+		entered := make(chan struct{}, 3)
+		release := make(chan struct{})
+		router := consumers.NewRouter()
+		router.Register("user.read", func(ctx context.Context, msg consumers.Message) error {
+			entered <- struct{}{}
+			select {
+			case <-ctx.Done(): // ✅ The deadline, when there is one
+			case <-release:
+			}
+			return ctx.Err()
+		})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		consumer := newTestConsumer(partition.reader(), router, infrastructure.NewMemoryProducer())
+		consumer.Workers = 1
+		consumer.MaxInFlight = 1
+		consumer.ShutdownTimeout = timeout
+		done := runConsumerContext(ctx, consumer)
+		waitFor(t, entered)
+		time.Sleep(20 * time.Millisecond) // ✅ Let the fetch loop block on the full window
+
+		cancel()
+		if timeout == 0 {
+			close(release) // ✅ Without a deadline, shutdown waits for the handler
+		}
+		waitFor(t, done)
+		require.NoError(t, consumer.Close())
+		assert.Len(t, entered, 0, "messages behind the full window were never started")
+	}
+}
@@ -0,0 +1,54 @@
+package websocket_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"GoSyntaxDoc/infrastructure"
+	"GoSyntaxDoc/infrastructure/consumers"
+)
+
+func TestDeadLetterQueueKeepsMessageAndAddsContext(t *testing.T) {
+	producer := infrastructure.NewMemoryProducer()
+	dlq := consumers.NewDeadLetterQueue(producer)
+
+	msg := consumers.Message{
+		Topic:     "user.created",
+		Partition: 2,
+		Offset:    41,
+		Key:       []byte("tenant-hash"),
+		Value:     []byte(`{not json`),
+		Headers:   infrastructure.Headers{infrastructure.HeaderEventID: "e-1"},
+	}
+	require.NoError(t, dlq.Publish(msg, 3, errors.New("decode user.created message: invalid character")))
+
+	produced := producer.Messages()
+	require.Len(t, produced, 1)
+	assert.Equal(t, "user.created.dlq", produced[0].Topic)
+	assert.Equal(t, "tenant-hash", produced[0].Key)
+	assert.Equal(t, msg.Value, produced[0].Value)
+
+	headers := produced[0].Headers
+	assert.Equal(t, "e-1", headers.Get(infrastructure.HeaderEventID))
+	assert.Equal(t, "user.created", headers.Get(consumers.HeaderDLQTopic))
+	assert.Equal(t, "2", headers.Get(consumers.HeaderDLQPartition))
+	assert.Equal(t, "41", headers.Get(consumers.HeaderDLQOffset))
+	assert.Equal(t, "3", headers.Get(consumers.HeaderDLQAttempts))
+	assert.Contains(t, headers.Get(consumers.HeaderDLQError), "invalid character")
+	assert.NotContains(t, msg.Headers, consumers.HeaderDLQTopic) // ✅ The original is untouched
+}
+
+func TestIsPermanent(t *testing.T) {
+	assert.True(t, consumers.IsPermanent(consumers.Permanent(errors.New("bad"))))
+	assert.True(t, consumers.IsPermanent(fmt.Errorf("wrapped: %w", consumers.Permanent(errors.New("bad")))))
+	assert.True(t, consumers.IsPermanent(&consumers.DecodeError{Topic: "t", Err: errors.New("bad")}))
+	assert.True(t, consumers.IsPermanent(&consumers.PanicError{Value: "boom"}))
+	assert.True(t, consumers.IsPermanent(fmt.Errorf("%w: t", consumers.ErrNoHandler)))
+
+	assert.False(t, consumers.IsPermanent(errors.New("connection refused")))
+	assert.Nil(t, consumers.Permanent(nil))
+}
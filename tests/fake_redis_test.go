@@ -0,0 +1,201 @@
+package websocket_test
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// ✅ fakeRedis - Just enough of a RESP2 server for PING, PUBLISH and (P)SUBSCRIBE
+// Tests publish through it and can drop every connection to exercise reconnects.
+type fakeRedis struct {
+	listener net.Listener
+
+	mu    sync.Mutex
+	conns map[net.Conn]*fakeRedisConn
+}
+
+type fakeRedisConn struct {
+	mu       sync.Mutex // Serialises writes
+	writer   *bufio.Writer
+	channels map[string]bool
+	patterns map[string]bool
+}
+
+// startFakeRedis listens on a free port and points REDIS_ADDR at it for the test
+func startFakeRedis(t *testing.T) *fakeRedis {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	r := &fakeRedis{listener: listener, conns: make(map[net.Conn]*fakeRedisConn)}
+	go r.serve()
+	t.Cleanup(func() {
+		_ = listener.Close()
+		r.DropConnections()
+	})
+	t.Setenv("REDIS_ADDR", listener.Addr().String())
+	return r
+}
+
+func (r *fakeRedis) serve() {
+	for {
+		conn, err := r.listener.Accept()
+		if err != nil {
+			return
+		}
+		state := &fakeRedisConn{writer: bufio.NewWriter(conn), channels: make(map[string]bool), patterns: make(map[string]bool)}
+		r.mu.Lock()
+		r.conns[conn] = state
+		r.mu.Unlock()
+		go r.handle(conn, state)
+	}
+}
+
+func (r *fakeRedis) handle(conn net.Conn, state *fakeRedisConn) {
+	defer func() {
+		r.mu.Lock()
+		delete(r.conns, conn)
+		r.mu.Unlock()
+		_ = conn.Close()
+	}()
+
+	reader := bufio.NewReader(conn)
+	for {
+		args, err := readCommand(reader)
+		if err != nil {
+			return
+		}
+		switch strings.ToUpper(args[0]) {
+		case "PING":
+			state.write("+PONG\r\n")
+		case "CLIENT":
+			state.write("+OK\r\n")
+		case "PUBLISH":
+			state.write(fmt.Sprintf(":%d\r\n", r.Publish(args[1], args[2])))
+		case "SUBSCRIBE", "PSUBSCRIBE":
+			kind := strings.ToLower(args[0])
+			r.mu.Lock()
+			for _, name := range args[1:] {
+				if kind == "subscribe" {
+					state.channels[name] = true
+				} else {
+					state.patterns[name] = true
+				}
+				state.write(fmt.Sprintf("*3\r\n%s%s:%d\r\n", bulk(kind), bulk(name), len(state.channels)+len(state.patterns)))
+			}
+			r.mu.Unlock()
+		case "UNSUBSCRIBE", "PUNSUBSCRIBE":
+			kind := strings.ToLower(args[0])
+			r.mu.Lock()
+			for _, name := range args[1:] {
+				delete(state.channels, name)
+				delete(state.patterns, name)
+				state.write(fmt.Sprintf("*3\r\n%s%s:%d\r\n", bulk(kind), bulk(name), len(state.channels)+len(state.patterns)))
+			}
+			r.mu.Unlock()
+		default:
+			// ✅ Includes HELLO, so go-redis falls back to RESP2
+			state.write(fmt.Sprintf("-ERR unknown command '%s'\r\n", args[0]))
+		}
+	}
+}
+
+// Publish delivers message to every connection subscribed to channel, returning how many got it
+func (r *fakeRedis) Publish(channel string, message string) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delivered := 0
+	for _, state := range r.conns {
+		if state.channels[channel] {
+			state.write(fmt.Sprintf("*3\r\n%s%s%s", bulk("message"), bulk(channel), bulk(message)))
+			delivered++
+		}
+		for pattern := range state.patterns {
+			if ok, _ := path.Match(pattern, channel); ok {
+				state.write(fmt.Sprintf("*4\r\n%s%s%s%s", bulk("pmessage"), bulk(pattern), bulk(channel), bulk(message)))
+				delivered++
+			}
+		}
+	}
+	return delivered
+}
+
+// Subscribed: Connections currently subscribed to channel
+func (r *fakeRedis) Subscribed(channel string) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	count := 0
+	for _, state := range r.conns {
+		if state.channels[channel] || state.patterns[channel] {
+			count++
+		}
+	}
+	return count
+}
+
+// WaitSubscribed blocks until n connections are subscribed to channel
+func (r *fakeRedis) WaitSubscribed(t *testing.T, channel string, n int) {
+	t.Helper()
+	require.Eventually(t, func() bool { return r.Subscribed(channel) == n }, 5*time.Second, 5*time.Millisecond)
+}
+
+// DropConnections closes every client connection, as a Redis restart would
+func (r *fakeRedis) DropConnections() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for conn := range r.conns {
+		_ = conn.Close()
+	}
+}
+
+func (c *fakeRedisConn) write(reply string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, _ = c.writer.WriteString(reply)
+	_ = c.writer.Flush()
+}
+
+func bulk(s string) string {
+	return "$" + strconv.Itoa(len(s)) + "\r\n" + s + "\r\n"
+}
+
+// readCommand reads one RESP array of bulk strings
+func readCommand(reader *bufio.Reader) ([]string, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(line, "*") {
+		return nil, fmt.Errorf("unexpected %q", line)
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(line[1:]))
+	if err != nil || n <= 0 {
+		return nil, fmt.Errorf("bad array header %q", line)
+	}
+	args := make([]string, n)
+	for i := range args {
+		header, err := reader.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		size, err := strconv.Atoi(strings.TrimSpace(header[1:]))
+		if err != nil {
+			return nil, fmt.Errorf("bad bulk header %q", header)
+		}
+		value := make([]byte, size+2)
+		if _, err := io.ReadFull(reader, value); err != nil {
+			return nil, err
+		}
+		args[i] = string(value[:size])
+	}
+	return args, nil
+}
@@ -0,0 +1,59 @@
+package websocket_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"GoSyntaxDoc/infrastructure/auth"
+)
+
+func TestJWTVerifierAcceptsValidToken(t *testing.T) {
+	key := []byte("secret-a")
+	verifier := auth.NewJWTVerifier(map[string][]byte{"a": key})
+
+	token, err := auth.Sign("HS384", "a", key, auth.Claims{
+		Subject:   "user-42",
+		Tenant:    "acme",
+		Roles:     []string{"admin"},
+		ExpiresAt: time.Now().Add(time.Minute).Unix(),
+	})
+	require.NoError(t, err)
+
+	identity, err := verifier.Verify(token)
+	require.NoError(t, err)
+	assert.Equal(t, "user-42", identity.Subject)
+	assert.Equal(t, "acme", identity.Tenant)
+	assert.True(t, identity.HasRole("admin"))
+}
+
+func TestJWTVerifierRejectsBadTokens(t *testing.T) {
+	key := []byte("secret-a")
+	verifier := auth.NewJWTVerifier(map[string][]byte{"a": key})
+	valid := auth.Claims{Subject: "user-42", ExpiresAt: time.Now().Add(time.Minute).Unix()}
+
+	wrongKey, _ := auth.Sign("HS256", "a", []byte("other"), valid)
+	_, err := verifier.Verify(wrongKey)
+	assert.ErrorIs(t, err, auth.ErrInvalidSignature)
+
+	unknownKid, _ := auth.Sign("HS256", "b", key, valid)
+	_, err = verifier.Verify(unknownKid)
+	assert.ErrorIs(t, err, auth.ErrUnknownKey)
+
+	expired := valid
+	expired.ExpiresAt = time.Now().Add(-time.Hour).Unix()
+	expiredToken, _ := auth.Sign("HS256", "a", key, expired)
+	_, err = verifier.Verify(expiredToken)
+	assert.ErrorIs(t, err, auth.ErrTokenExpired)
+
+	noSubject := valid
+	noSubject.Subject = ""
+	noSubjectToken, _ := auth.Sign("HS256", "a", key, noSubject)
+	_, err = verifier.Verify(noSubjectToken)
+	assert.ErrorIs(t, err, auth.ErrInvalidClaims)
+
+	_, err = verifier.Verify("not-a-token")
+	assert.ErrorIs(t, err, auth.ErrMalformedToken)
+}
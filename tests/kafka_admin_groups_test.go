@@ -0,0 +1,198 @@
+package websocket_test
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/protocol"
+	"github.com/segmentio/kafka-go/protocol/describegroups"
+	"github.com/segmentio/kafka-go/protocol/listoffsets"
+	"github.com/segmentio/kafka-go/protocol/metadata"
+	"github.com/segmentio/kafka-go/protocol/offsetcommit"
+	"github.com/segmentio/kafka-go/protocol/offsetfetch"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"GoSyntaxDoc/infrastructure/kafkaadmin"
+)
+
+// clusterPartition - What the fake cluster stores for one partition
+type clusterPartition struct {
+	start, end int64
+	byTime     map[int64]int64 // Timestamp → first offset at or after it
+}
+
+// ✅ fakeCluster - A kafka.RoundTripper answering the admin requests the group tooling
+// sends, the way a broker does: earliest and latest offsets come back with timestamp -1
+type fakeCluster struct {
+	topics  map[string][]clusterPartition
+	members []string // Members of the group; none means Empty
+
+	mu        sync.Mutex
+	committed map[string]map[int32]int64
+	commits   int
+}
+
+func (c *fakeCluster) RoundTrip(ctx context.Context, addr net.Addr, req protocol.Message) (protocol.Message, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	switch req := req.(type) {
+	case *metadata.Request:
+		res := &metadata.Response{Brokers: []metadata.ResponseBroker{{NodeID: 1, Host: "fake", Port: 9092}}}
+		for name, partitions := range c.topics {
+			topic := metadata.ResponseTopic{Name: name}
+			for i := range partitions {
+				topic.Partitions = append(topic.Partitions, metadata.ResponsePartition{PartitionIndex: int32(i), LeaderID: 1})
+			}
+			res.Topics = append(res.Topics, topic)
+		}
+		return res, nil
+
+	case *listoffsets.Request:
+		res := &listoffsets.Response{}
+		for _, topic := range req.Topics {
+			answer := listoffsets.ResponseTopic{Topic: topic.Topic}
+			for _, asked := range topic.Partitions {
+				partition := c.topics[topic.Topic][asked.Partition]
+				found := listoffsets.ResponsePartition{Partition: asked.Partition, Timestamp: -1, Offset: -1}
+				switch asked.Timestamp {
+				case kafka.FirstOffset:
+					found.Offset = partition.start
+				case kafka.LastOffset:
+					found.Offset = partition.end
+				default:
+					if offset, ok := partition.byTime[asked.Timestamp]; ok {
+						found.Timestamp, found.Offset = asked.Timestamp, offset
+					}
+				}
+				answer.Partitions = append(answer.Partitions, found)
+			}
+			res.Topics = append(res.Topics, answer)
+		}
+		return res, nil
+
+	case *describegroups.Request:
+		res := &describegroups.Response{}
+		for _, id := range req.Groups {
+			group := describegroups.ResponseGroup{GroupID: id, GroupState: "Empty", ProtocolType: "consumer"}
+			for _, member := range c.members {
+				group.GroupState = "Stable"
+				group.Members = append(group.Members, describegroups.ResponseGroupMember{MemberID: member, ClientID: member, ClientHost: "/127.0.0.1"})
+			}
+			res.Groups = append(res.Groups, group)
+		}
+		return res, nil
+
+	case *offsetfetch.Request:
+		res := &offsetfetch.Response{}
+		for name, partitions := range c.committed {
+			topic := offsetfetch.ResponseTopic{Name: name}
+			for partition, offset := range partitions {
+				topic.Partitions = append(topic.Partitions, offsetfetch.ResponsePartition{PartitionIndex: partition, CommittedOffset: offset})
+			}
+			res.Topics = append(res.Topics, topic)
+		}
+		return res, nil
+
+	case *offsetcommit.Request:
+		c.commits++
+		res := &offsetcommit.Response{}
+		for _, topic := range req.Topics {
+			answer := offsetcommit.ResponseTopic{Name: topic.Name}
+			if c.committed[topic.Name] == nil {
+				c.committed[topic.Name] = make(map[int32]int64)
+			}
+			for _, partition := range topic.Partitions {
+				c.committed[topic.Name][partition.PartitionIndex] = partition.CommittedOffset
+				answer.Partitions = append(answer.Partitions, offsetcommit.ResponsePartition{PartitionIndex: partition.PartitionIndex})
+			}
+			res.Topics = append(res.Topics, answer)
+		}
+		return res, nil
+	}
+	return nil, fmt.Errorf("fake cluster: unexpected %T", req)
+}
+
+// newGroupAdmin: An Admin on a cluster whose user.created has retention-trimmed
+// partitions, with group "users" committed on partition 0 only
+func newGroupAdmin() (*kafkaadmin.Admin, *fakeCluster) {
+	cluster := &fakeCluster{
+		topics: map[string][]clusterPartition{
+			"user.created": {
+				{start: 40, end: 100, byTime: map[int64]int64{1000: 55}},
+				{start: 5, end: 10},
+			},
+		},
+		committed: map[string]map[int32]int64{"user.created": {0: 70}},
+	}
+	client := &kafka.Client{Addr: kafka.TCP("fake:9092"), Timeout: time.Second, Transport: cluster}
+	return &kafkaadmin.Admin{Client: client}, cluster
+}
+
+func sortChanges(changes []kafkaadmin.OffsetChange) []kafkaadmin.OffsetChange {
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Partition < changes[j].Partition })
+	return changes
+}
+
+func TestLagReadsEachPartitionsStart(t *testing.T) {
+	admin, _ := newGroupAdmin()
+
+	offsets, err := admin.Lag(context.Background(), "users")
+	require.NoError(t, err)
+	assert.Equal(t, []kafkaadmin.PartitionOffsets{
+		{Topic: "user.created", Partition: 0, Start: 40, End: 100, Committed: 70},
+		{Topic: "user.created", Partition: 1, Start: 5, End: 10, Committed: -1},
+	}, offsets)
+	assert.Equal(t, int64(30), offsets[0].Lag())
+	assert.Equal(t, int64(5), offsets[1].Lag(), "an uncommitted partition counts from its start, not 0")
+}
+
+func TestPlanResetResolvesAgainstTheBroker(t *testing.T) {
+	admin, cluster := newGroupAdmin()
+	ctx := context.Background()
+
+	changes, err := admin.PlanReset(ctx, "users", nil, kafkaadmin.ResetTarget{Kind: kafkaadmin.ResetEarliest})
+	require.NoError(t, err)
+	assert.Equal(t, []kafkaadmin.OffsetChange{
+		{Topic: "user.created", Partition: 0, From: 70, To: 40},
+		{Topic: "user.created", Partition: 1, From: -1, To: 5},
+	}, sortChanges(changes))
+
+	changes, err = admin.PlanReset(ctx, "users", []string{"user.created"}, kafkaadmin.ResetTarget{Kind: kafkaadmin.ResetOffset, Offset: 0})
+	require.NoError(t, err)
+	assert.Equal(t, int64(40), changes[0].To, "clamped to the retained start")
+	assert.Equal(t, int64(5), changes[1].To)
+
+	// ✅ Partitions without a message at or after the time move to the end
+	changes, err = admin.PlanReset(ctx, "users", nil, kafkaadmin.ResetTarget{Kind: kafkaadmin.ResetTimestamp, Time: time.UnixMilli(1000)})
+	require.NoError(t, err)
+	assert.Equal(t, int64(55), changes[0].To)
+	assert.Equal(t, int64(10), changes[1].To)
+
+	_, err = admin.PlanReset(ctx, "users", []string{"order.created"}, kafkaadmin.ResetTarget{Kind: kafkaadmin.ResetLatest})
+	assert.ErrorContains(t, err, "topic order.created not found")
+	assert.Zero(t, cluster.commits, "planning writes nothing")
+}
+
+func TestApplyResetCommitsOnlyForIdleGroups(t *testing.T) {
+	admin, cluster := newGroupAdmin()
+	ctx := context.Background()
+
+	changes, err := admin.PlanReset(ctx, "users", nil, kafkaadmin.ResetTarget{Kind: kafkaadmin.ResetEarliest})
+	require.NoError(t, err)
+	require.NoError(t, admin.ApplyReset(ctx, "users", changes))
+	assert.Equal(t, map[int32]int64{0: 40, 1: 5}, cluster.committed["user.created"])
+
+	cluster.members = []string{"consumer-1"}
+	err = admin.ApplyReset(ctx, "users", []kafkaadmin.OffsetChange{{Topic: "user.created", Partition: 0, To: 100}})
+	assert.ErrorContains(t, err, "active member")
+	assert.Equal(t, 1, cluster.commits)
+	assert.Equal(t, int64(40), cluster.committed["user.created"][0])
+}
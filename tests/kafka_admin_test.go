@@ -0,0 +1,120 @@
+package websocket_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"GoSyntaxDoc/domain/topics"
+	"GoSyntaxDoc/infrastructure/kafkaadmin"
+)
+
+func TestParseSpecAcceptsYAMLAndJSON(t *testing.T) {
+	yamlSpec, err := kafkaadmin.ParseSpec([]byte(`
+topics:
+  - name: orders
+    partitions: 6
+    replication_factor: 3
+    retention: 72h
+    cleanup_policy: delete
+    min_insync_replicas: 2
+`))
+	require.NoError(t, err)
+	jsonSpec, err := kafkaadmin.ParseSpec([]byte(`{"topics": [{"name": "orders", "partitions": 6, "replication_factor": 3,
+		"retention": "72h", "cleanup_policy": "delete", "min_insync_replicas": 2}]}`))
+	require.NoError(t, err)
+
+	assert.Equal(t, yamlSpec, jsonSpec)
+	assert.Equal(t, map[string]string{
+		"retention.ms":        "259200000",
+		"cleanup.policy":      "delete",
+		"min.insync.replicas": "2",
+	}, yamlSpec.Topics[0].Configs())
+}
+
+func TestParseSpecReportsEveryProblem(t *testing.T) {
+	_, err := kafkaadmin.ParseSpec([]byte(`
+topics:
+  - name: orders
+    partitions: 0
+    replication_factor: 1
+    min_insync_replicas: 2
+  - name: orders
+    partitions: 1
+    replication_factor: 1
+    cleanup_policy: shred
+`))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "partitions must be at least 1")
+	assert.Contains(t, err.Error(), "min_insync_replicas")
+	assert.Contains(t, err.Error(), "declared more than once")
+	assert.Contains(t, err.Error(), `unknown cleanup_policy "shred"`)
+}
+
+func TestPlanCreatesMissingTopicsAndFixesDrift(t *testing.T) {
+	spec := kafkaadmin.Spec{Topics: []kafkaadmin.TopicSpec{
+		{Name: "new", Partitions: 3, ReplicationFactor: 1, Retention: time.Hour},
+		{Name: "grown", Partitions: 4, ReplicationFactor: 1, CleanupPolicy: "compact"},
+		{Name: "shrunk", Partitions: 1, ReplicationFactor: 3},
+		{Name: "same", Partitions: 2, ReplicationFactor: 1, Retention: time.Hour},
+	}}
+	current := map[string]kafkaadmin.TopicState{
+		"grown":  {Name: "grown", Partitions: 2, ReplicationFactor: 1, Configs: map[string]string{"cleanup.policy": "delete"}},
+		"shrunk": {Name: "shrunk", Partitions: 2, ReplicationFactor: 3},
+		"same":   {Name: "same", Partitions: 2, ReplicationFactor: 1, Configs: map[string]string{"retention.ms": "3600000"}},
+		"other":  {Name: "other", Partitions: 9, ReplicationFactor: 1},
+	}
+
+	plan := kafkaadmin.NewPlan(spec, current)
+
+	require.True(t, plan.Drift())
+	assert.Equal(t, []string{
+		"+ new partitions=3 replication_factor=1 retention.ms=3600000",
+		"~ grown partitions: 2 → 4",
+		"~ grown cleanup.policy: delete → compact",
+		"! shrunk partitions: 2 → 1 (change it by hand)",
+	}, changeLines(plan))
+	assert.Len(t, plan.Unsupported(), 1)
+}
+
+func TestCatalogSpecMatchesACatalogCluster(t *testing.T) {
+	spec := kafkaadmin.SpecFromCatalog(topics.All())
+	require.NoError(t, spec.Validate())
+
+	created := kafkaadmin.NewPlan(spec, nil)
+	assert.Len(t, created.Changes, len(topics.All()))
+
+	current := make(map[string]kafkaadmin.TopicState)
+	for _, topic := range spec.Topics {
+		current[topic.Name] = kafkaadmin.TopicState{
+			Name: topic.Name, Partitions: topic.Partitions, ReplicationFactor: topic.ReplicationFactor, Configs: topic.Configs(),
+		}
+	}
+	assert.False(t, kafkaadmin.NewPlan(spec, current).Drift())
+}
+
+func changeLines(plan kafkaadmin.Plan) []string {
+	lines := make([]string, 0, len(plan.Changes))
+	for _, change := range plan.Changes {
+		lines = append(lines, change.String())
+	}
+	return lines
+}
+
+func TestPartitionLag(t *testing.T) {
+	assert.Equal(t, int64(40), kafkaadmin.PartitionOffsets{Start: 10, End: 100, Committed: 60}.Lag())
+	assert.Equal(t, int64(90), kafkaadmin.PartitionOffsets{Start: 10, End: 100, Committed: -1}.Lag(), "uncommitted partitions are read from the start")
+	assert.Equal(t, int64(0), kafkaadmin.PartitionOffsets{Start: 10, End: 100, Committed: 100}.Lag())
+}
+
+func TestResetTargetResolve(t *testing.T) {
+	partition := kafkaadmin.PartitionOffsets{Start: 10, End: 100, Committed: 60}
+
+	assert.Equal(t, int64(10), kafkaadmin.ResetTarget{Kind: kafkaadmin.ResetEarliest}.Resolve(partition))
+	assert.Equal(t, int64(100), kafkaadmin.ResetTarget{Kind: kafkaadmin.ResetLatest}.Resolve(partition))
+	assert.Equal(t, int64(42), kafkaadmin.ResetTarget{Kind: kafkaadmin.ResetOffset, Offset: 42}.Resolve(partition))
+	assert.Equal(t, int64(10), kafkaadmin.ResetTarget{Kind: kafkaadmin.ResetOffset, Offset: 3}.Resolve(partition), "clamped to the start")
+	assert.Equal(t, int64(100), kafkaadmin.ResetTarget{Kind: kafkaadmin.ResetOffset, Offset: 500}.Resolve(partition), "clamped to the end")
+}
@@ -0,0 +1,36 @@
+package websocket_test
+
+import (
+	"testing"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+
+	"GoSyntaxDoc/infrastructure"
+)
+
+func TestHeadersRoundTrip(t *testing.T) {
+	headers := infrastructure.Headers{
+		infrastructure.HeaderTraceparent:   infrastructure.NewTraceparent(),
+		infrastructure.HeaderCorrelationID: "r-1",
+	}
+
+	converted := headers.Kafka()
+	assert.Equal(t, infrastructure.HeaderCorrelationID, converted[0].Key) // ✅ Sorted by name
+	assert.Equal(t, headers, infrastructure.HeadersFromKafka(converted))
+
+	assert.Nil(t, infrastructure.Headers(nil).Kafka())
+	assert.Equal(t, "", infrastructure.Headers(nil).Get(infrastructure.HeaderEventID))
+}
+
+func TestHeadersFromKafkaLastValueWins(t *testing.T) {
+	headers := infrastructure.HeadersFromKafka([]kafka.Header{
+		{Key: infrastructure.HeaderRequestID, Value: []byte("a")},
+		{Key: infrastructure.HeaderRequestID, Value: []byte("b")},
+	})
+	assert.Equal(t, "b", headers.Get(infrastructure.HeaderRequestID))
+}
+
+func TestNewTraceparentIsUnique(t *testing.T) {
+	assert.NotEqual(t, infrastructure.NewTraceparent(), infrastructure.NewTraceparent())
+}
@@ -0,0 +1,126 @@
+package websocket_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/protocol"
+	"github.com/segmentio/kafka-go/protocol/metadata"
+	"github.com/segmentio/kafka-go/protocol/produce"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"GoSyntaxDoc/infrastructure"
+)
+
+// ✅ fakeBroker - A kafka.RoundTripper that answers metadata and produce requests,
+// taking a random time for each write, and records what it was sent per key
+type fakeBroker struct {
+	partitions int
+
+	mu    sync.Mutex
+	byKey map[string][]string
+}
+
+func newFakeBroker(partitions int) *fakeBroker {
+	return &fakeBroker{partitions: partitions, byKey: make(map[string][]string)}
+}
+
+func (b *fakeBroker) RoundTrip(ctx context.Context, addr net.Addr, req protocol.Message) (protocol.Message, error) {
+	switch req := req.(type) {
+	case *metadata.Request:
+		res := &metadata.Response{Brokers: []metadata.ResponseBroker{{NodeID: 1, Host: "fake", Port: 9092}}}
+		for _, name := range req.TopicNames {
+			topic := metadata.ResponseTopic{Name: name}
+			for i := 0; i < b.partitions; i++ {
+				topic.Partitions = append(topic.Partitions, metadata.ResponsePartition{PartitionIndex: int32(i), LeaderID: 1})
+			}
+			res.Topics = append(res.Topics, topic)
+		}
+		return res, nil
+
+	case *produce.Request:
+		time.Sleep(time.Duration(rand.Intn(3)) * time.Millisecond)
+		res := &produce.Response{}
+		for _, topic := range req.Topics {
+			answer := produce.ResponseTopic{Topic: topic.Topic}
+			for _, partition := range topic.Partitions {
+				if err := b.record(partition.RecordSet.Records); err != nil {
+					return nil, err
+				}
+				answer.Partitions = append(answer.Partitions, produce.ResponsePartition{Partition: partition.Partition})
+			}
+			res.Topics = append(res.Topics, answer)
+		}
+		return res, nil
+	}
+	return nil, fmt.Errorf("fake broker: unexpected %T", req)
+}
+
+func (b *fakeBroker) record(records protocol.RecordReader) error {
+	for {
+		record, err := records.ReadRecord()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		key, _ := protocol.ReadAll(record.Key)
+		value, _ := protocol.ReadAll(record.Value)
+		b.mu.Lock()
+		b.byKey[string(key)] = append(b.byKey[string(key)], string(value))
+		b.mu.Unlock()
+	}
+}
+
+func TestProduceAsyncKeepsPerKeyOrder(t *testing.T) {
+	broker := newFakeBroker(4)
+	cfg := infrastructure.DefaultKafkaProducerConfig([]string{"fake:9092"})
+	cfg.BatchSize = 3
+	cfg.Linger = time.Millisecond
+	cfg.Compression = kafka.Compression(0)
+	cfg.Transport = broker
+	producer := infrastructure.NewKafkaProducer(cfg)
+
+	const keys, perKey = 8, 50
+	var mu sync.Mutex
+	var failures []error
+	for i := 0; i < perKey; i++ {
+		for k := 0; k < keys; k++ {
+			producer.ProduceAsync(infrastructure.Message{
+				Topic: "user.fetch",
+				Key:   "user-" + strconv.Itoa(k),
+				Value: []byte(strconv.Itoa(i)),
+			}, func(report infrastructure.DeliveryReport) {
+				if report.Err != nil {
+					mu.Lock()
+					failures = append(failures, report.Err)
+					mu.Unlock()
+				}
+			})
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	require.NoError(t, producer.Flush(ctx))
+	require.NoError(t, producer.Close())
+	assert.Empty(t, failures)
+
+	require.Len(t, broker.byKey, keys)
+	for key, values := range broker.byKey {
+		require.Len(t, values, perKey, key)
+		for i, value := range values {
+			assert.Equal(t, strconv.Itoa(i), value, "%s out of order", key)
+		}
+	}
+}
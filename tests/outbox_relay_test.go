@@ -0,0 +1,488 @@
+package websocket_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"GoSyntaxDoc/domain/events"
+	"GoSyntaxDoc/domain/topics"
+	"GoSyntaxDoc/infrastructure"
+	"GoSyntaxDoc/infrastructure/database"
+	"GoSyntaxDoc/infrastructure/database/migrations"
+	"GoSyntaxDoc/infrastructure/outbox"
+)
+
+// headerLabel names test rows, so produced messages can be told apart
+const headerLabel = "test-label"
+
+// outboxRow - What a test can see of a stored row
+type outboxRow struct {
+	Exists    bool
+	Attempts  int
+	KafkaSent bool
+	Sent      bool
+	Parked    bool
+	LastError string
+}
+
+// outboxBackend - A relay store the tests can seed and inspect
+type outboxBackend interface {
+	outbox.Store
+	Add(t *testing.T, msg outbox.Message) int64
+	Row(t *testing.T, id int64) outboxRow
+}
+
+// forEachOutbox runs test on the in-memory store, then on Postgres when one is reachable
+func forEachOutbox(t *testing.T, test func(t *testing.T, store outboxBackend)) {
+	t.Run("memory", func(t *testing.T) { test(t, &memoryOutbox{}) })
+	t.Run("postgres", func(t *testing.T) { test(t, newPostgresOutbox(t)) })
+}
+
+// ✅ memoryOutbox - outbox.Store with the semantics of the outbox table queries
+type memoryOutbox struct {
+	mu   sync.Mutex // Stands in for the claim's advisory lock
+	rows []*memoryRow
+}
+
+type memoryRow struct {
+	msg         outbox.Message
+	nextAttempt time.Time
+	kafkaSent   bool
+	sentAt      *time.Time
+	parked      bool
+	lastError   string
+}
+
+func (s *memoryOutbox) Add(t *testing.T, msg outbox.Message) int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	msg.ID = int64(len(s.rows) + 1)
+	s.rows = append(s.rows, &memoryRow{msg: msg, nextAttempt: time.Now()})
+	return msg.ID
+}
+
+func (s *memoryOutbox) Row(t *testing.T, id int64) outboxRow {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	row := s.find(id)
+	if row == nil {
+		return outboxRow{}
+	}
+	return outboxRow{Exists: true, Attempts: row.msg.Attempts, KafkaSent: row.kafkaSent, Sent: row.sentAt != nil, Parked: row.parked, LastError: row.lastError}
+}
+
+func (s *memoryOutbox) Claim(ctx context.Context, limit int, lease time.Duration) ([]outbox.Message, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	var due []*memoryRow
+	for i, row := range s.rows {
+		if len(due) == limit {
+			break
+		}
+		if row.sentAt != nil || row.parked || row.nextAttempt.After(now) {
+			continue
+		}
+		waiting := false // ✅ An earlier row of the key is leased or backing off before Kafka
+		for _, earlier := range s.rows[:i] {
+			if row.msg.Key != "" && earlier.msg.Topic == row.msg.Topic && earlier.msg.Key == row.msg.Key &&
+				!earlier.kafkaSent && !earlier.parked && earlier.nextAttempt.After(now) {
+				waiting = true
+			}
+		}
+		if !waiting {
+			due = append(due, row)
+		}
+	}
+
+	batch := make([]outbox.Message, 0, len(due))
+	for _, row := range due {
+		row.nextAttempt = now.Add(lease)
+		msg := row.msg
+		msg.KafkaSent = row.kafkaSent
+		batch = append(batch, msg)
+	}
+	return batch, nil
+}
+
+func (s *memoryOutbox) Release(ctx context.Context, id int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.find(id).nextAttempt = time.Now()
+	return nil
+}
+
+func (s *memoryOutbox) MarkSent(ctx context.Context, id int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	row, now := s.find(id), time.Now()
+	row.kafkaSent, row.sentAt, row.lastError = true, &now, ""
+	return nil
+}
+
+func (s *memoryOutbox) Fail(ctx context.Context, id int64, failure outbox.Failure) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	row := s.find(id)
+	row.msg.Attempts = failure.Attempts
+	row.lastError = failure.Error
+	row.kafkaSent = row.kafkaSent || failure.KafkaSent
+	row.nextAttempt = time.Now().Add(failure.RetryIn)
+	row.parked = failure.Park
+	return nil
+}
+
+func (s *memoryOutbox) DeleteSent(ctx context.Context, before time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	kept := s.rows[:0]
+	for _, row := range s.rows {
+		if row.sentAt == nil || !row.sentAt.Before(before) {
+			kept = append(kept, row)
+		}
+	}
+	deleted := int64(len(s.rows) - len(kept))
+	s.rows = kept
+	return deleted, nil
+}
+
+func (s *memoryOutbox) find(id int64) *memoryRow {
+	for _, row := range s.rows {
+		if row.msg.ID == id {
+			return row
+		}
+	}
+	return nil
+}
+
+// ✅ postgresOutbox - The real store, in a schema of its own that is dropped afterwards
+type postgresOutbox struct {
+	*outbox.PostgresStore
+}
+
+func newPostgresOutbox(t *testing.T) *postgresOutbox {
+	t.Helper()
+	if os.Getenv("DB_HOST") == "" {
+		t.Skip("integration test: DB_HOST is not set")
+	}
+	port := os.Getenv("DB_PORT")
+	if port == "" {
+		port = "5432"
+	}
+	requireReachable(t, net.JoinHostPort(os.Getenv("DB_HOST"), port))
+
+	ctx := context.Background()
+	dsn := fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=disable",
+		os.Getenv("DB_USER"), os.Getenv("DB_PASSWORD"), os.Getenv("DB_HOST"), port, os.Getenv("DB_NAME"))
+	schema := fmt.Sprintf("outbox_test_%d", time.Now().UnixNano())
+
+	admin, err := pgxpool.New(ctx, dsn)
+	require.NoError(t, err)
+	t.Cleanup(admin.Close)
+	_, err = admin.Exec(ctx, `CREATE SCHEMA `+schema)
+	require.NoError(t, err)
+	t.Cleanup(func() { _, _ = admin.Exec(context.Background(), `DROP SCHEMA `+schema+` CASCADE`) })
+
+	config, err := pgxpool.ParseConfig(dsn)
+	require.NoError(t, err)
+	config.ConnConfig.RuntimeParams["search_path"] = schema
+	pool, err := pgxpool.NewWithConfig(ctx, config)
+	require.NoError(t, err)
+	t.Cleanup(pool.Close)
+
+	// ✅ The migration creates the table through the shared handle
+	previous := database.Database
+	database.Database = database.DBinstance{DB: pool}
+	defer func() { database.Database = previous }()
+	migrations.InitOutbox()
+
+	return &postgresOutbox{PostgresStore: outbox.NewPostgresStore(pool)}
+}
+
+func (s *postgresOutbox) Add(t *testing.T, msg outbox.Message) int64 {
+	t.Helper()
+	ctx := context.Background()
+	tx, err := s.DB.Begin(ctx)
+	require.NoError(t, err)
+	defer tx.Rollback(ctx)
+	require.NoError(t, outbox.Insert(ctx, tx, msg))
+	var id int64
+	require.NoError(t, tx.QueryRow(ctx, `SELECT currval(pg_get_serial_sequence('outbox', 'id'))`).Scan(&id))
+	require.NoError(t, tx.Commit(ctx))
+	return id
+}
+
+func (s *postgresOutbox) Row(t *testing.T, id int64) outboxRow {
+	t.Helper()
+	rows, err := s.DB.Query(context.Background(),
+		`SELECT attempts, kafka_sent_at IS NOT NULL, sent_at IS NOT NULL, parked_at IS NOT NULL, COALESCE(last_error, '')
+		 FROM outbox WHERE id = $1`, id)
+	require.NoError(t, err)
+	defer rows.Close()
+	row := outboxRow{}
+	if rows.Next() {
+		row.Exists = true
+		require.NoError(t, rows.Scan(&row.Attempts, &row.KafkaSent, &row.Sent, &row.Parked, &row.LastError))
+	}
+	require.NoError(t, rows.Err())
+	return row
+}
+
+// ✅ relayProducer - Records produced rows by label and fails the labels in failing
+type relayProducer struct {
+	*infrastructure.MemoryProducer
+	failing map[string]bool
+
+	mu       sync.Mutex
+	attempts map[string]int
+}
+
+func newRelayProducer(failing ...string) *relayProducer {
+	p := &relayProducer{MemoryProducer: infrastructure.NewMemoryProducer(), failing: make(map[string]bool), attempts: make(map[string]int)}
+	for _, label := range failing {
+		p.failing[label] = true
+	}
+	return p
+}
+
+func (p *relayProducer) Produce(msg infrastructure.Message) error {
+	label := msg.Headers.Get(headerLabel)
+	p.mu.Lock()
+	p.attempts[label]++
+	p.mu.Unlock()
+	if p.failing[label] {
+		return errors.New("broker unavailable")
+	}
+	return p.MemoryProducer.Produce(msg)
+}
+
+func (p *relayProducer) Attempts(label string) int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.attempts[label]
+}
+
+// Labels: What reached Kafka, in order
+func (p *relayProducer) Labels() []string {
+	var labels []string
+	for _, msg := range p.Messages() {
+		labels = append(labels, msg.Headers.Get(headerLabel))
+	}
+	return labels
+}
+
+type publishFunc func(channel string, message string) error
+
+func (f publishFunc) Publish(channel string, message string) error { return f(channel, message) }
+
+// newTestRelay: A relay on store that parks rows after two failures
+func newTestRelay(store outbox.Store, producer infrastructure.Producer) *outbox.Relay {
+	return &outbox.Relay{
+		Store:        store,
+		Producer:     producer,
+		RedisService: publishFunc(func(string, string) error { return nil }),
+		BatchSize:    10,
+		Lease:        time.Minute,
+		RetryBackoff: 100 * time.Millisecond,
+		MaxAttempts:  2,
+		Retention:    time.Hour,
+	}
+}
+
+// addEvent stores a user event under key, labelled so the producer can tell it apart
+func addEvent(t *testing.T, store outboxBackend, key string, label string) int64 {
+	t.Helper()
+	msg, err := outbox.NewMessage(topics.UserEvents, key, events.NewEnvelope("user", "created", "", map[string]string{"label": label}), nil)
+	require.NoError(t, err)
+	msg.Headers[headerLabel] = label
+	return store.Add(t, msg)
+}
+
+func claimedIDs(batch []outbox.Message) []int64 {
+	ids := make([]int64, 0, len(batch))
+	for _, msg := range batch {
+		ids = append(ids, msg.ID)
+	}
+	return ids
+}
+
+func TestRelaysNeverClaimTheSameRow(t *testing.T) {
+	forEachOutbox(t, func(t *testing.T, store outboxBackend) {
+		ctx := context.Background()
+		a1 := addEvent(t, store, "a", "a1")
+		b1 := addEvent(t, store, "b", "b1")
+		addEvent(t, store, "a", "a2")
+		c1 := addEvent(t, store, "c", "c1")
+
+		first, err := store.Claim(ctx, 2, time.Minute)
+		require.NoError(t, err)
+		assert.Equal(t, []int64{a1, b1}, claimedIDs(first))
+
+		// ✅ Leased rows are skipped, and so is a2, which waits behind the leased a1
+		second, err := store.Claim(ctx, 10, time.Minute)
+		require.NoError(t, err)
+		assert.Equal(t, []int64{c1}, claimedIDs(second))
+
+		third, err := store.Claim(ctx, 10, time.Minute)
+		require.NoError(t, err)
+		assert.Empty(t, third)
+	})
+}
+
+func TestConcurrentRelaysDeliverEachRowOnceInKeyOrder(t *testing.T) {
+	forEachOutbox(t, func(t *testing.T, store outboxBackend) {
+		const perKey = 10
+		keys := []string{"a", "b", "c", ""}
+		for i := 0; i < perKey; i++ {
+			for _, key := range keys {
+				addEvent(t, store, key, fmt.Sprintf("%s%02d", key, i))
+			}
+		}
+		producer := newRelayProducer()
+
+		var stop atomic.Bool
+		var wg sync.WaitGroup
+		for range 2 {
+			relay := newTestRelay(store, producer)
+			relay.BatchSize = 3
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for !stop.Load() {
+					if _, err := relay.RelayBatch(context.Background()); err != nil {
+						t.Error(err)
+						return
+					}
+				}
+			}()
+		}
+		assert.Eventually(t, func() bool { return len(producer.Messages()) >= perKey*len(keys) }, 10*time.Second, 5*time.Millisecond)
+		stop.Store(true)
+		wg.Wait()
+
+		labels := producer.Labels()
+		require.Len(t, labels, perKey*len(keys), "every row exactly once")
+		byKey := make(map[string][]string)
+		for _, msg := range producer.Messages() {
+			byKey[msg.Key] = append(byKey[msg.Key], msg.Headers.Get(headerLabel))
+		}
+		for _, key := range keys[:3] {
+			assert.True(t, sort.StringsAreSorted(byKey[key]), "key %q out of order: %v", key, byKey[key])
+		}
+	})
+}
+
+func TestFailingRowBlocksItsKeyUntilParked(t *testing.T) {
+	forEachOutbox(t, func(t *testing.T, store outboxBackend) {
+		ctx := context.Background()
+		failing := addEvent(t, store, "k", "k1")
+		behind := addEvent(t, store, "k", "k2")
+		addEvent(t, store, "other", "o1")
+		producer := newRelayProducer("k1")
+		relay := newTestRelay(store, producer)
+
+		sent, err := relay.RelayBatch(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 1, sent)
+		assert.Equal(t, []string{"o1"}, producer.Labels(), "other keys carry on")
+		assert.Equal(t, outboxRow{Exists: true, Attempts: 1, LastError: "kafka: broker unavailable"}, store.Row(t, failing))
+
+		// ✅ While k1 backs off, k2 is not even claimed
+		sent, err = relay.RelayBatch(ctx)
+		require.NoError(t, err)
+		assert.Zero(t, sent)
+		assert.Zero(t, producer.Attempts("k2"))
+		assert.Equal(t, outboxRow{Exists: true}, store.Row(t, behind))
+
+		// ✅ The retry fails too, parking k1 and letting k2 through
+		require.Eventually(t, func() bool {
+			_, err := relay.RelayBatch(ctx)
+			assert.NoError(t, err)
+			return store.Row(t, behind).Sent
+		}, 5*time.Second, 10*time.Millisecond)
+		assert.Equal(t, []string{"o1", "k2"}, producer.Labels())
+		assert.Equal(t, 2, producer.Attempts("k1"))
+		row := store.Row(t, failing)
+		assert.True(t, row.Parked)
+		assert.False(t, row.KafkaSent)
+		assert.Equal(t, 2, row.Attempts)
+	})
+}
+
+func TestParkedRowsAreNotRetried(t *testing.T) {
+	forEachOutbox(t, func(t *testing.T, store outboxBackend) {
+		ctx := context.Background()
+		exhausted := addEvent(t, store, "", "x1")
+		undecodable, err := outbox.NewMessage(topics.UserEvents, "", events.NewEnvelope("user", "created", "", nil), nil)
+		require.NoError(t, err)
+		undecodable.Payload = []byte(`"not an envelope"`)
+		undecodable.Headers[headerLabel] = "u1"
+		permanent := store.Add(t, undecodable)
+		producer := newRelayProducer("x1")
+		relay := newTestRelay(store, producer)
+
+		// ✅ Undecodable rows reach Kafka, then park on the first fan-out failure
+		_, err = relay.RelayBatch(ctx)
+		require.NoError(t, err)
+		row := store.Row(t, permanent)
+		assert.True(t, row.Parked)
+		assert.True(t, row.KafkaSent)
+		assert.False(t, row.Sent)
+		assert.Equal(t, 1, row.Attempts)
+
+		require.Eventually(t, func() bool {
+			_, err := relay.RelayBatch(ctx)
+			assert.NoError(t, err)
+			return store.Row(t, exhausted).Parked
+		}, 5*time.Second, 10*time.Millisecond)
+
+		// ✅ Well past every backoff, nothing is claimed or produced again
+		time.Sleep(3 * relay.RetryBackoff)
+		for range 3 {
+			sent, err := relay.RelayBatch(ctx)
+			require.NoError(t, err)
+			assert.Zero(t, sent)
+		}
+		assert.Equal(t, relay.MaxAttempts, producer.Attempts("x1"))
+		assert.Equal(t, 1, producer.Attempts("u1"))
+		assert.Equal(t, relay.MaxAttempts, store.Row(t, exhausted).Attempts)
+		assert.Equal(t, 1, store.Row(t, permanent).Attempts)
+	})
+}
+
+func TestCleanupDeletesOnlyOldSentRows(t *testing.T) {
+	forEachOutbox(t, func(t *testing.T, store outboxBackend) {
+		ctx := context.Background()
+		sent := addEvent(t, store, "", "s1")
+		parked := addEvent(t, store, "", "p1")
+		relay := newTestRelay(store, newRelayProducer("p1"))
+		relay.MaxAttempts = 1
+		_, err := relay.RelayBatch(ctx)
+		require.NoError(t, err)
+		require.True(t, store.Row(t, sent).Sent)
+		require.True(t, store.Row(t, parked).Parked)
+
+		deleted, err := relay.Cleanup(ctx)
+		require.NoError(t, err)
+		assert.Zero(t, deleted, "sent within the retention")
+
+		relay.Retention = -time.Minute // ✅ Everything sent so far is past it
+		deleted, err = relay.Cleanup(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), deleted)
+		assert.False(t, store.Row(t, sent).Exists)
+		assert.True(t, store.Row(t, parked).Exists, "parked rows stay for inspection")
+	})
+}
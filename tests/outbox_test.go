@@ -0,0 +1,60 @@
+package websocket_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"GoSyntaxDoc/domain/events"
+	"GoSyntaxDoc/domain/topics"
+	"GoSyntaxDoc/infrastructure"
+	"GoSyntaxDoc/infrastructure/outbox"
+)
+
+func TestOutboxMessageCarriesOriginHeaders(t *testing.T) {
+	origin := infrastructure.Headers{
+		infrastructure.HeaderEventID:       "command-event",
+		infrastructure.HeaderCorrelationID: "r-1",
+		infrastructure.HeaderConnectionID:  "conn-1",
+		infrastructure.HeaderTraceparent:   "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01",
+		infrastructure.HeaderSchemaVersion: "7",
+	}
+	envelope := events.NewEnvelope("user", "created", "r-1", map[string]interface{}{"id": 7})
+
+	msg, err := outbox.NewMessage(topics.UserEvents, "7", envelope, origin)
+	require.NoError(t, err)
+	assert.Equal(t, "user.events", msg.Topic)
+	assert.Equal(t, "7", msg.Key)
+
+	// ✅ The event gets its own ID and schema version; routing and trace context carry over
+	assert.Equal(t, envelope.EventID, msg.Headers.Get(infrastructure.HeaderEventID))
+	assert.Equal(t, "1", msg.Headers.Get(infrastructure.HeaderSchemaVersion))
+	assert.Equal(t, "r-1", msg.Headers.Get(infrastructure.HeaderCorrelationID))
+	assert.Equal(t, "conn-1", msg.Headers.Get(infrastructure.HeaderConnectionID))
+	assert.Equal(t, origin.Get(infrastructure.HeaderTraceparent), msg.Headers.Get(infrastructure.HeaderTraceparent))
+	assert.NotContains(t, msg.Headers, infrastructure.HeaderRequestID)
+
+	var decoded events.Envelope
+	require.NoError(t, json.Unmarshal(msg.Payload, &decoded))
+	assert.Equal(t, "user.created", decoded.Name())
+}
+
+func TestOutboxMessageWithoutOrigin(t *testing.T) {
+	msg, err := outbox.NewMessage(topics.UserEvents, "", events.NewEnvelope("user", "created", "", nil), nil)
+	require.NoError(t, err)
+	assert.Empty(t, msg.Headers.Get(infrastructure.HeaderConnectionID))
+	assert.NotEmpty(t, msg.Headers.Get(infrastructure.HeaderEventID))
+}
+
+func TestRelayRetryDelayDoublesUpToACap(t *testing.T) {
+	relay := &outbox.Relay{RetryBackoff: time.Second}
+
+	assert.Equal(t, time.Second, relay.RetryDelay(1))
+	assert.Equal(t, 2*time.Second, relay.RetryDelay(2))
+	assert.Equal(t, 8*time.Second, relay.RetryDelay(4))
+	assert.Equal(t, 5*time.Minute, relay.RetryDelay(20))
+	assert.Equal(t, 5*time.Minute, relay.RetryDelay(1000))
+}
@@ -0,0 +1,47 @@
+package websocket_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"GoSyntaxDoc/infrastructure"
+)
+
+func TestDefaultKeyStrategies(t *testing.T) {
+	keys := infrastructure.DefaultKeyStrategies()
+
+	key, err := keys.Key("user.fetch", infrastructure.KeySource{Value: []byte(`{"data": {"user_id": 12345678901}}`)})
+	require.NoError(t, err)
+	assert.Equal(t, "12345678901", key)
+
+	_, err = keys.Key("user.fetch", infrastructure.KeySource{Value: []byte(`{"data": {}}`)})
+	assert.Error(t, err)
+
+	// ✅ Same tenant, same key, whichever connection sent it
+	a, err := keys.Key("user.created", infrastructure.KeySource{Tenant: "acme", ConnectionID: "c-1"})
+	require.NoError(t, err)
+	b, err := keys.Key("user.created", infrastructure.KeySource{Tenant: "acme", ConnectionID: "c-2"})
+	require.NoError(t, err)
+	assert.Equal(t, a, b)
+	assert.NotEqual(t, "acme", a)
+
+	// ✅ Falls back to the session when there is no tenant
+	key, err = keys.Key("user.created", infrastructure.KeySource{ConnectionID: "c-1"})
+	require.NoError(t, err)
+	assert.Equal(t, "c-1", key)
+
+	// ✅ User events land with the commands about the same user
+	event := []byte(`{"event": "user", "type": "created", "payload": {"id": 7, "first_name": "Ada"}}`)
+	key, err = keys.Key("user.events", infrastructure.KeySource{Value: event, Tenant: "acme"})
+	require.NoError(t, err)
+	fetch, err := keys.Key("user.fetch", infrastructure.KeySource{Value: []byte(`{"data": {"user_id": 7}}`)})
+	require.NoError(t, err)
+	assert.Equal(t, "7", key)
+	assert.Equal(t, fetch, key)
+
+	key, err = keys.Key("unregistered.topic", infrastructure.KeySource{ConnectionID: "c-1"})
+	require.NoError(t, err)
+	assert.Empty(t, key)
+}
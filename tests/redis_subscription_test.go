@@ -0,0 +1,60 @@
+package websocket_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"GoSyntaxDoc/infrastructure/redis"
+)
+
+func TestSubscriptionOutlivesADroppedConnection(t *testing.T) {
+	fake := startFakeRedis(t)
+	redisService := redis.NewRedisService()
+	defer redisService.Close()
+
+	received := make(chan string, 4)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	sub, err := redisService.SubscribeConfirmed(ctx, "order.created", func(msg string) { received <- msg })
+	require.NoError(t, err)
+	assert.Equal(t, 1, fake.Subscribed("order.created"), "confirmed means Redis already has it")
+
+	fake.Publish("order.created", "first")
+	assert.Equal(t, "first", receive(t, received))
+
+	// ✅ A Redis restart: the receive loop keeps going and go-redis resubscribes
+	fake.DropConnections()
+	fake.WaitSubscribed(t, "order.created", 1)
+	fake.Publish("order.created", "second")
+	assert.Equal(t, "second", receive(t, received))
+
+	require.NoError(t, sub.Close())
+	fake.WaitSubscribed(t, "order.created", 0)
+}
+
+func TestSubscribeConfirmedFailsWithoutRedis(t *testing.T) {
+	t.Setenv("REDIS_ADDR", "127.0.0.1:1")
+	redisService := redis.NewRedisService()
+	defer redisService.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	sub, err := redisService.SubscribeConfirmed(ctx, "order.created", func(string) {})
+	assert.Error(t, err)
+	assert.Nil(t, sub)
+}
+
+func receive(t *testing.T, messages <-chan string) string {
+	t.Helper()
+	select {
+	case msg := <-messages:
+		return msg
+	case <-time.After(5 * time.Second):
+		t.Fatal("no message received")
+		return ""
+	}
+}
@@ -0,0 +1,87 @@
+package websocket_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"GoSyntaxDoc/infrastructure"
+	"GoSyntaxDoc/infrastructure/consumers"
+)
+
+func TestRetryTopicNames(t *testing.T) {
+	assert.Equal(t, "user.created.retry.10s", consumers.RetryTopic("user.created", 10*time.Second))
+	assert.Equal(t, "user.created.retry.1m", consumers.RetryTopic("user.created", time.Minute))
+	assert.Equal(t, "user.created.retry.10m", consumers.RetryTopic("user.created", 10*time.Minute))
+	assert.Equal(t, "user.created.retry.2h", consumers.RetryTopic("user.created", 2*time.Hour))
+	assert.Equal(t, "user.created.retry.90s", consumers.RetryTopic("user.created", 90*time.Second))
+}
+
+func TestRetryQueueWalksTheTiers(t *testing.T) {
+	producer := infrastructure.NewMemoryProducer()
+	queue := consumers.NewRetryQueue(producer, consumers.DefaultRetryDelays)
+	cause := errors.New("connection refused")
+
+	msg := consumers.Message{
+		Topic:     "user.created",
+		Partition: 1,
+		Offset:    9,
+		Key:       []byte("k"),
+		Value:     []byte(`{}`),
+		Headers:   infrastructure.Headers{infrastructure.HeaderEventID: "e-1"},
+	}
+	for attempt, topic := range []string{"user.created.retry.10s", "user.created.retry.1m", "user.created.retry.10m"} {
+		msg.Attempt = attempt + 1
+		scheduled, err := queue.Schedule(msg, cause)
+		require.NoError(t, err)
+		assert.True(t, scheduled)
+		assert.Equal(t, topic, producer.Messages()[attempt].Topic)
+	}
+
+	// ✅ The fourth failure has no tier left and belongs in the DLQ
+	msg.Attempt = 4
+	assert.True(t, queue.LastAttempt(4))
+	scheduled, err := queue.Schedule(msg, cause)
+	require.NoError(t, err)
+	assert.False(t, scheduled)
+	assert.Len(t, producer.Messages(), 3)
+
+	first := producer.Messages()[0]
+	assert.Equal(t, "k", first.Key)
+	assert.Equal(t, "e-1", first.Headers.Get(infrastructure.HeaderEventID))
+	assert.Equal(t, "user.created", first.Headers.Get(consumers.HeaderRetryTopic))
+	assert.Equal(t, "1", first.Headers.Get(consumers.HeaderRetryPartition))
+	assert.Equal(t, "9", first.Headers.Get(consumers.HeaderRetryOffset))
+	assert.Equal(t, "1", first.Headers.Get(consumers.HeaderRetryAttempt))
+	assert.Equal(t, "connection refused", first.Headers.Get(consumers.HeaderRetryError))
+
+	notBefore, err := strconv.ParseInt(first.Headers.Get(consumers.HeaderRetryNotBefore), 10, 64)
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(10*time.Second), time.UnixMilli(notBefore), time.Second)
+}
+
+func TestIsTransient(t *testing.T) {
+	assert.True(t, consumers.IsTransient(consumers.Transient(errors.New("busy"))))
+	assert.True(t, consumers.IsTransient(fmt.Errorf("fetch user: %w", context.DeadlineExceeded)))
+	assert.True(t, consumers.IsTransient(&pgconn.PgError{Code: "40P01"}))
+	assert.True(t, consumers.IsTransient(&pgconn.PgError{Code: "08006"}))
+
+	assert.False(t, consumers.IsTransient(&pgconn.PgError{Code: "23505"})) // unique_violation
+	assert.False(t, consumers.IsTransient(errors.New("unclassified")))
+	assert.False(t, consumers.IsTransient(consumers.Permanent(consumers.Transient(errors.New("both")))))
+}
+
+func TestWillRetry(t *testing.T) {
+	transient := consumers.Transient(errors.New("busy"))
+	assert.True(t, consumers.Message{Attempt: 1}.WillRetry(transient))
+	assert.False(t, consumers.Message{Attempt: 4, LastAttempt: true}.WillRetry(transient))
+	assert.False(t, consumers.Message{Attempt: 1}.WillRetry(errors.New("unclassified")))
+	assert.False(t, consumers.Message{Attempt: 1}.WillRetry(nil))
+}
@@ -0,0 +1,66 @@
+package websocket_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"GoSyntaxDoc/infrastructure/schema"
+	wsm "GoSyntaxDoc/presentation/websocket"
+)
+
+func decode(t *testing.T, src string) interface{} {
+	var v interface{}
+	require.NoError(t, json.Unmarshal([]byte(src), &v))
+	return v
+}
+
+func TestDefaultEventRegistryValidatesPayloads(t *testing.T) {
+	registry := wsm.DefaultEventRegistry()
+
+	spec, ok := registry.Lookup("user", "created")
+	require.True(t, ok)
+	assert.Equal(t, "user.created", spec.Topic)
+	assert.NoError(t, spec.Schema.Validate(decode(t, `{"first_name": "Ada", "last_name": "Lovelace"}`)))
+	assert.Error(t, spec.Schema.Validate(decode(t, `{"first_name": ""}`)))
+	assert.Error(t, spec.Schema.Validate(decode(t, `{"first_name": "Ada", "last_name": "L", "admin": true}`)))
+
+	spec, ok = registry.Lookup("user", "fetch")
+	require.True(t, ok)
+	assert.NoError(t, spec.Schema.Validate(decode(t, `{"user_id": 7}`)))
+	assert.Error(t, spec.Schema.Validate(decode(t, `{"user_id": 7.5}`)))
+	assert.Error(t, spec.Schema.Validate(decode(t, `{"user_id": "7"}`)))
+
+	_, ok = registry.Lookup("user", "deleted")
+	assert.False(t, ok)
+	_, ok = registry.Lookup("__consumer_offsets", "")
+	assert.False(t, ok)
+}
+
+func TestCompileRejectsUnsupportedKeywords(t *testing.T) {
+	_, err := schema.Compile(`{"type": "object", "properties": {"name": {"type": "string", "maxLenght": 5}}}`)
+	assert.ErrorContains(t, err, "maxLenght")
+	_, err = schema.Compile(`{"type": "string", "pattern": "^[a-z]+$"}`)
+	assert.ErrorContains(t, err, "pattern")
+	_, err = schema.Compile(`{"type": "array", "items": {"format": "email"}}`)
+	assert.ErrorContains(t, err, "format")
+	_, err = schema.Compile(`{"type": "string"} {"type": "number"}`)
+	assert.Error(t, err)
+
+	s, err := schema.Compile(`{"type": "string", "maxLength": 5}`)
+	require.NoError(t, err)
+	assert.Error(t, s.Validate("too long"))
+}
+
+func TestIntegersMustFitAGoInt(t *testing.T) {
+	s := schema.MustCompile(`{"type": "integer"}`)
+	assert.NoError(t, s.Validate(decode(t, `9007199254740993`)))
+	assert.NoError(t, s.Validate(decode(t, `-9223372036854775808`)))
+
+	err := s.Validate(decode(t, `1e20`))
+	assert.ErrorContains(t, err, "integer out of range")
+	assert.Error(t, s.Validate(decode(t, `9223372036854775808`)))
+	assert.Error(t, s.Validate(decode(t, `-1e19`)))
+}
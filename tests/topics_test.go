@@ -0,0 +1,54 @@
+package websocket_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"GoSyntaxDoc/domain/topics"
+	"GoSyntaxDoc/infrastructure/consumers"
+	wsm "GoSyntaxDoc/presentation/websocket"
+)
+
+func TestGatewayCommandsAreAcceptedAndConsumed(t *testing.T) {
+	registry := wsm.DefaultEventRegistry()
+	consumed := topics.ConsumedBy(topics.ServiceUsers)
+
+	commands := topics.ProducedBy(topics.ServiceGateway)
+	require.NotEmpty(t, commands)
+	for _, topic := range commands {
+		spec, ok := registry.Lookup(topic.Event, topic.Type)
+		require.True(t, ok, topic.Name)
+		assert.Equal(t, topic.Name, spec.Topic)
+		assert.Contains(t, consumed, topic.Name)
+	}
+}
+
+func TestConsumedTopicsHaveHandlers(t *testing.T) {
+	router := consumers.NewRouter()
+	consumers.NewUserHandlers(nil, nil).Register(router)
+
+	assert.ElementsMatch(t, topics.ConsumedBy(topics.ServiceUsers), router.Topics())
+}
+
+func TestAllTopicsIncludesRetryAndDeadLetterTopics(t *testing.T) {
+	names := make(map[string]bool)
+	for _, topic := range topics.All() {
+		assert.False(t, names[topic.Name], "duplicate topic %s", topic.Name)
+		names[topic.Name] = true
+		assert.Positive(t, topic.Partitions, topic.Name)
+		assert.Positive(t, topic.ReplicationFactor, topic.Name)
+	}
+
+	assert.True(t, names[topics.UserEvents])
+	assert.False(t, names["user.create"])
+	assert.True(t, names["user.created.retry.1m"])
+	assert.True(t, names["user.created.dlq"])
+	assert.False(t, names["user.events.dlq"], "user.events is not consumed here")
+
+	topic, ok := topics.Lookup(topics.Retry(topics.UserFetch, time.Minute))
+	require.True(t, ok)
+	assert.Equal(t, []string{topics.ServiceUsers}, topic.Consumers)
+}
@@ -0,0 +1,31 @@
+package websocket_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"GoSyntaxDoc/domain/entities"
+)
+
+// ✅ Stored results are replayed to duplicate commands, so users must survive a JSON round trip
+func TestUserJSONRoundTrip(t *testing.T) {
+	user := entities.User{
+		ID:        7,
+		FirstName: "Ada",
+		LastName:  "Lovelace",
+		CreatedAt: entities.JSONTime{Time: time.Date(2025, 1, 1, 11, 59, 58, 0, time.UTC)},
+	}
+	data, err := json.Marshal(user)
+	require.NoError(t, err)
+
+	var decoded entities.User
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, user, decoded)
+
+	require.NoError(t, json.Unmarshal([]byte(`{"id": 1, "created_at": ""}`), &decoded))
+	assert.True(t, decoded.CreatedAt.IsZero())
+}
@@ -0,0 +1,204 @@
+package websocket_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"GoSyntaxDoc/domain/entities"
+	"GoSyntaxDoc/domain/events"
+	"GoSyntaxDoc/domain/topics"
+	"GoSyntaxDoc/infrastructure"
+	"GoSyntaxDoc/infrastructure/consumers"
+	"GoSyntaxDoc/infrastructure/outbox"
+	"GoSyntaxDoc/services/user"
+)
+
+// fakeUserStore claims event IDs like the processed_events ledger
+type fakeUserStore struct {
+	users   []entities.User
+	claimed map[string]*entities.User
+	outbox  []outbox.Message
+	readErr error // Returned by FetchAllUsers when set
+}
+
+func newFakeUserStore() *fakeUserStore {
+	return &fakeUserStore{claimed: make(map[string]*entities.User)}
+}
+
+func (s *fakeUserStore) FetchUserById(userID int) (*entities.User, error) {
+	for i := range s.users {
+		if s.users[i].ID == userID {
+			return &s.users[i], nil
+		}
+	}
+	return nil, user.ErrUserNotFound
+}
+
+func (s *fakeUserStore) FetchAllUsers() ([]entities.User, error) {
+	if s.readErr != nil {
+		return nil, s.readErr
+	}
+	return s.users, nil
+}
+
+func (s *fakeUserStore) CreateUser(eventID string, firstName string, lastName string, newEvent func(*entities.User) (outbox.Message, error)) (*entities.User, bool, error) {
+	if previous, ok := s.claimed[eventID]; ok {
+		return previous, false, nil
+	}
+	created := entities.User{ID: len(s.users) + 1, FirstName: firstName, LastName: lastName}
+	msg, err := newEvent(&created)
+	if err != nil {
+		return nil, false, err
+	}
+	s.users = append(s.users, created)
+	s.outbox = append(s.outbox, msg)
+	s.claimed[eventID] = &created
+	return &created, true, nil
+}
+
+// fakePublisher records what would have gone to Redis
+type fakePublisher struct {
+	mu        sync.Mutex
+	published []events.RedisMessage
+}
+
+func (p *fakePublisher) Publish(channel string, message string) error {
+	if channel != consumers.RedisChannel {
+		return errors.New("unexpected channel " + channel)
+	}
+	var msg events.RedisMessage
+	if err := json.Unmarshal([]byte(message), &msg); err != nil {
+		return err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.published = append(p.published, msg)
+	return nil
+}
+
+func (p *fakePublisher) messages() []events.RedisMessage {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]events.RedisMessage(nil), p.published...)
+}
+
+func TestUserCreatedDuplicateRepublishesTheFirstResult(t *testing.T) {
+	store := newFakeUserStore()
+	publisher := &fakePublisher{}
+	replies := consumers.NewReplies(publisher)
+
+	// ✅ The consumer's chain, so a duplicate must get through it to the ledger
+	router := consumers.NewRouter()
+	router.Use(consumers.Logging(), consumers.Recovery())
+	consumers.NewUserHandlers(user.NewUserService(store), replies).Register(router)
+
+	command := consumers.Message{
+		Topic: topics.UserCreated,
+		Value: []byte(`{"event": "user", "type": "created", "data": {"first_name": "Ada", "last_name": "Lovelace"}}`),
+		Headers: infrastructure.Headers{
+			infrastructure.HeaderEventID:       "evt-1",
+			infrastructure.HeaderConnectionID:  "c-1",
+			infrastructure.HeaderCorrelationID: "req-1",
+		},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	// ✅ First delivery: the user and its event are recorded; the relay sends the reply
+	require.NoError(t, router.Dispatch(ctx, command))
+	require.NoError(t, replies.Wait(ctx))
+	require.Len(t, store.users, 1)
+	require.Len(t, store.outbox, 1)
+	assert.Equal(t, topics.UserEvents, store.outbox[0].Topic)
+	assert.Equal(t, "1", store.outbox[0].Key, "keyed by user through DefaultKeyStrategies")
+	assert.Equal(t, "c-1", store.outbox[0].Headers.Get(infrastructure.HeaderConnectionID))
+	assert.Empty(t, publisher.messages())
+
+	// ✅ Redelivery: nothing new is recorded and the first result is answered again
+	require.NoError(t, router.Dispatch(ctx, command))
+	require.NoError(t, replies.Wait(ctx))
+	assert.Len(t, store.users, 1)
+	assert.Len(t, store.outbox, 1)
+
+	published := publisher.messages()
+	require.Len(t, published, 1)
+	assert.Equal(t, "c-1", published[0].ConnectionID)
+	assert.Equal(t, "created", published[0].Envelope.Type)
+	assert.Equal(t, "req-1", published[0].Envelope.CorrelationID)
+	payload, err := json.Marshal(published[0].Envelope.Payload)
+	require.NoError(t, err)
+	var replied entities.User
+	require.NoError(t, json.Unmarshal(payload, &replied))
+	assert.Equal(t, store.users[0].ID, replied.ID)
+	assert.Equal(t, "Ada", replied.FirstName)
+}
+
+// newUserRouter: The user handlers on a fake store, replying through a fake publisher
+func newUserRouter(store *fakeUserStore) (*consumers.Router, *consumers.Replies, *fakePublisher) {
+	publisher := &fakePublisher{}
+	replies := consumers.NewReplies(publisher)
+	router := consumers.NewRouter()
+	consumers.NewUserHandlers(user.NewUserService(store), replies).Register(router)
+	return router, replies, publisher
+}
+
+func TestUserHandlerFailuresReplyWithFailedEnvelopes(t *testing.T) {
+	cases := []struct {
+		name    string
+		topic   string
+		value   string
+		last    bool
+		readErr error
+
+		wantErr  bool
+		wantType string // Empty when nothing may be published
+		wantCode string
+	}{
+		{name: "undecodable", topic: topics.UserFetch, value: `{"connection_id": "c-1", "request_id": "req-1", "data": "x"}`,
+			wantErr: true, wantType: "fetch.failed", wantCode: events.ReasonInvalidPayload},
+		{name: "undecodable without a route", topic: topics.UserFetch, value: `{"data": "x"}`, wantErr: true},
+		{name: "validation", topic: topics.UserFetch, value: `{"connection_id": "c-1", "request_id": "req-1", "data": {"user_id": 0}}`,
+			wantType: "fetch.failed", wantCode: events.ReasonValidation},
+		{name: "not found", topic: topics.UserFetch, value: `{"connection_id": "c-1", "request_id": "req-1", "data": {"user_id": 42}}`,
+			wantType: "fetch.failed", wantCode: events.ReasonNotFound},
+		{name: "transient, retried", topic: topics.UserRead, value: `{"connection_id": "c-1", "request_id": "req-1"}`,
+			readErr: context.DeadlineExceeded, wantErr: true},
+		{name: "transient, last attempt", topic: topics.UserRead, value: `{"connection_id": "c-1", "request_id": "req-1"}`,
+			readErr: context.DeadlineExceeded, last: true, wantErr: true, wantType: "read.failed", wantCode: events.ReasonInternal},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			store := newFakeUserStore()
+			store.readErr = tc.readErr
+			router, replies, publisher := newUserRouter(store)
+
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+			err := router.Dispatch(ctx, consumers.Message{Topic: tc.topic, Value: []byte(tc.value), LastAttempt: tc.last})
+			assert.Equal(t, tc.wantErr, err != nil, "handler error: %v", err)
+			require.NoError(t, replies.Wait(ctx))
+
+			published := publisher.messages()
+			if tc.wantType == "" {
+				assert.Empty(t, published)
+				return
+			}
+			require.Len(t, published, 1)
+			assert.Equal(t, "c-1", published[0].ConnectionID)
+			envelope := published[0].Envelope
+			assert.Equal(t, "user", envelope.Event)
+			assert.Equal(t, tc.wantType, envelope.Type)
+			assert.Equal(t, "req-1", envelope.CorrelationID)
+			require.NotNil(t, envelope.Error)
+			assert.Equal(t, tc.wantCode, envelope.Error.Code)
+			assert.NotContains(t, envelope.Error.Message, "deadline", "internal errors are not leaked")
+		})
+	}
+}
@@ -1,6 +1,12 @@
 package websocket_test
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
 	"testing"
 	"time"
 
@@ -10,62 +16,274 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"GoSyntaxDoc/infrastructure"
+	"GoSyntaxDoc/infrastructure/auth"
 	"GoSyntaxDoc/infrastructure/redis"
 	wsm "GoSyntaxDoc/presentation/websocket"
 )
 
-// ✅ Mock Kafka Producer (Only If You Want to Avoid Real Kafka)
-type MockKafkaProducer struct{}
+var testKey = []byte("test-secret")
 
-func (m *MockKafkaProducer) ProduceMessage(topic, key, value string) error {
-	return nil
+// ✅ startGateway serves the WebSocket routes on a free port and returns an authenticated client
+func startGateway(t *testing.T, producer infrastructure.Producer, redisService *redis.RedisService) *websocket.Conn {
+	t.Helper()
+	url := serveGateway(t, wsm.NewWebSocketManager(producer, redisService, wsm.DefaultConfig()))
+	return dialGateway(t, url, auth.Claims{Subject: "user-1", Tenant: "tenant-1"})
+}
+
+// serveGateway serves manager's routes on a free port and returns their ws:// URL
+func serveGateway(t *testing.T, manager *wsm.WebSocketManager) string {
+	t.Helper()
+
+	app := fiber.New(fiber.Config{DisableStartupMessage: true})
+	verifier := auth.NewJWTVerifier(map[string][]byte{auth.DefaultKeyID: testKey})
+	wsm.RegisterWebsocketRoutes(app, manager, verifier)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go func() {
+		_ = app.Listener(listener)
+	}()
+	t.Cleanup(func() { _ = app.Shutdown() })
+	return "ws://" + listener.Addr().String() + "/ws"
+}
+
+// dialGateway connects with a token carrying claims, valid for an hour
+func dialGateway(t *testing.T, url string, claims auth.Claims) *websocket.Conn {
+	t.Helper()
+
+	claims.ExpiresAt = time.Now().Add(time.Hour).Unix()
+	token, err := auth.Sign("HS256", "", testKey, claims)
+	require.NoError(t, err)
+
+	header := http.Header{"Authorization": []string{"Bearer " + token}}
+	client, _, err := websocket.DefaultDialer.Dial(url, header)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = client.Close() })
+	return client
+}
+
+func readFrame(t *testing.T, client *websocket.Conn) map[string]interface{} {
+	t.Helper()
+	_ = client.SetReadDeadline(time.Now().Add(5 * time.Second))
+	_, data, err := client.ReadMessage()
+	require.NoError(t, err)
+
+	var frame map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &frame))
+	return frame
+}
+
+func TestWebSocketGatewayWithoutBrokers(t *testing.T) {
+	t.Setenv("REDIS_ADDR", "127.0.0.1:1") // ✅ Nothing listens here; replies are not exercised
+	redisService := redis.NewRedisService()
+	defer redisService.Close()
+
+	producer := infrastructure.NewMemoryProducer()
+	client := startGateway(t, producer, redisService)
+
+	// ✅ A valid command is produced and acknowledged
+	message := `{"type": "created", "event": "user", "request_id": "r-1", "data": {"first_name": "RAID", "last_name": "Suline"}}`
+	require.NoError(t, client.WriteMessage(websocket.TextMessage, []byte(message)))
+
+	frame := readFrame(t, client)
+	assert.Equal(t, "ack", frame["type"])
+	assert.Equal(t, "r-1", frame["request_id"])
+
+	produced := producer.Messages()
+	require.Len(t, produced, 1)
+	assert.Equal(t, "user.created", produced[0].Topic)
+
+	var command map[string]interface{}
+	require.NoError(t, json.Unmarshal(produced[0].Value, &command))
+	assert.NotEmpty(t, command["connection_id"])
+	assert.Equal(t, "user-1", command["identity"].(map[string]interface{})["sub"])
+
+	headers := produced[0].Headers
+	assert.NotEmpty(t, headers.Get(infrastructure.HeaderEventID))
+	assert.Equal(t, command["event_id"], headers.Get(infrastructure.HeaderEventID))
+	assert.Equal(t, "r-1", headers.Get(infrastructure.HeaderCorrelationID))
+	assert.Equal(t, "r-1", headers.Get(infrastructure.HeaderRequestID))
+	assert.Equal(t, "1", headers.Get(infrastructure.HeaderSchemaVersion))
+	assert.Equal(t, infrastructure.ContentTypeJSON, headers.Get(infrastructure.HeaderContentType))
+	assert.Equal(t, command["connection_id"], headers.Get(infrastructure.HeaderConnectionID))
+	assert.Equal(t, "user-1", headers.Get(infrastructure.HeaderUserSubject))
+	assert.Equal(t, "tenant-1", headers.Get(infrastructure.HeaderUserTenant))
+	assert.Regexp(t, `^00-[0-9a-f]{32}-[0-9a-f]{16}-01$`, headers.Get(infrastructure.HeaderTraceparent))
+
+	// ✅ Unknown events never reach the producer
+	require.NoError(t, client.WriteMessage(websocket.TextMessage, []byte(`{"type": "dropped", "event": "user", "request_id": "r-2"}`)))
+	frame = readFrame(t, client)
+	assert.Equal(t, "nack", frame["type"])
+	assert.Equal(t, "unknown_event", frame["code"])
+	assert.Len(t, producer.Messages(), 1)
+
+	// ✅ Broker failures are reported as retryable
+	producer.Err = errors.New("broker unavailable")
+	require.NoError(t, client.WriteMessage(websocket.TextMessage, []byte(`{"type": "fetch", "event": "user", "request_id": "r-3", "data": {"user_id": 1}}`)))
+	frame = readFrame(t, client)
+	assert.Equal(t, "nack", frame["type"])
+	assert.Equal(t, "produce_failed", frame["code"])
+	assert.Equal(t, true, frame["retryable"])
 }
 
 func TestWebSocketWithRealRedisAndKafka(t *testing.T) {
-	// ✅ Start Real Kafka (Ensure it's running at localhost:9092)
-	realKafkaProducer := &infrastructure.KafkaProducer{
-		Brokers: []string{"localhost:9092"},
-	}
+	// ✅ Needs Kafka at localhost:9092 and Redis at REDIS_ADDR (e.g. `make up`)
+	requireReachable(t, "localhost:9092")
+	requireReachable(t, os.Getenv("REDIS_ADDR"))
 
-	// ✅ Initialize Fiber app
-	app := fiber.New()
+	realKafkaProducer := infrastructure.NewKafkaProducer(infrastructure.DefaultKafkaProducerConfig([]string{"localhost:9092"}))
+	defer realKafkaProducer.Close()
 
 	// ✅ Use Real RedisService (make sure Redis is running!)
 	realRedis := redis.NewRedisService()
 	defer realRedis.Close() // Cleanup Redis connection
 
-	// ✅ Create WebSocketManager with real Kafka and Redis
-	manager := wsm.NewWebSocketManager(realKafkaProducer, realRedis)
+	client := startGateway(t, realKafkaProducer, realRedis)
+
+	// ✅ Send test message
+	message := `{"type": "created", "event": "user", "request_id": "r-1", "data": {"first_name": "RAID", "last_name": "Suline"}}`
+	err := client.WriteMessage(websocket.TextMessage, []byte(message))
+	require.NoError(t, err)
 
-	// ✅ Register WebSocket route
-	wsm.RegisterWebsocketRoutes(app, manager)
+	frame := readFrame(t, client)
+	assert.Equal(t, "ack", frame["type"])
+}
 
-	// ✅ Start test server
-	go func() {
-		_ = app.Listen(":8080")
-	}()
-	defer app.Shutdown()
+func requireReachable(t *testing.T, addr string) {
+	t.Helper()
+	if addr == "" {
+		t.Skip("integration test: REDIS_ADDR is not set")
+	}
+	conn, err := net.DialTimeout("tcp", addr, time.Second)
+	if err != nil {
+		t.Skipf("integration test: %s is not reachable: %v", addr, err)
+	}
+	conn.Close()
+}
 
-	// ✅ Allow time for server to start
-	time.Sleep(1 * time.Second)
+func TestWebSocketSubscriptionsWithoutRedis(t *testing.T) {
+	t.Setenv("REDIS_ADDR", "127.0.0.1:1") // ✅ Nothing listens here, so Redis never confirms
+	redisService := redis.NewRedisService()
+	defer redisService.Close()
 
-	// ✅ Use Gorilla WebSocket Client
-	client, _, err := websocket.DefaultDialer.Dial("ws://localhost:8080/ws", nil)
-	require.NoError(t, err)
-	defer client.Close()
+	client := startGateway(t, infrastructure.NewMemoryProducer(), redisService)
 
-	// ✅ Send test message
-	message := `{"type": "created", "event": "user", "data": {"first_name": "RAID", "last_name": "Suline"}}`
-	err = client.WriteMessage(websocket.TextMessage, []byte(message))
-	require.NoError(t, err)
+	// ✅ Pipelined, so a second subscribe can arrive while the first is pending
+	for i, frame := range []string{"subscribe", "subscribe", "unsubscribe"} {
+		message := fmt.Sprintf(`{"type": %q, "channel": "order.created", "request_id": "s-%d"}`, frame, i)
+		require.NoError(t, client.WriteMessage(websocket.TextMessage, []byte(message)))
+	}
+	for i := 0; i < 2; i++ {
+		frame := readFrame(t, client)
+		assert.Equal(t, "error", frame["type"])
+		assert.Equal(t, wsm.ErrCodeInvalidSubscription, frame["code"])
+		assert.Equal(t, fmt.Sprintf("s-%d", i), frame["request_id"])
+	}
+	frame := readFrame(t, client)
+	assert.Equal(t, "unsubscribed", frame["type"])
+}
 
-	// ✅ Publish message to Redis (simulating external event)
-	err = realRedis.Publish("users_actions", `{"type": "created", "event": "user", "data": {"first_name": "RAID", "last_name": "Suline"}}`)
-	require.NoError(t, err)
+func TestWebSocketChannelSubscriptions(t *testing.T) {
+	fake := startFakeRedis(t)
+	redisService := redis.NewRedisService()
+	defer redisService.Close()
+
+	client := startGateway(t, infrastructure.NewMemoryProducer(), redisService)
+
+	// ✅ Pipelined so subscribing, resubscribing and leaving a channel overlap
+	frames := []string{"subscribe", "subscribe", "unsubscribe", "subscribe", "unsubscribe", "unsubscribe", "subscribe"}
+	for i, frame := range frames {
+		message := fmt.Sprintf(`{"type": %q, "channel": "order.created", "request_id": "s-%d"}`, frame, i)
+		require.NoError(t, client.WriteMessage(websocket.TextMessage, []byte(message)))
+	}
+	for i, frame := range frames {
+		reply := readFrame(t, client)
+		assert.Equal(t, frame+"d", reply["type"])
+		assert.Equal(t, "order.created", reply["channel"])
+		assert.Equal(t, fmt.Sprintf("s-%d", i), reply["request_id"])
+	}
+
+	// ✅ One Redis subscription for the channel, confirmed before the reply, so this broadcast arrives
+	fake.WaitSubscribed(t, "order.created", 1)
+	fake.Publish("order.created", `{"tenant": "tenant-1", "envelope": {"event": "order", "type": "created", "payload": {"id": 1}}}`)
+	frame := readFrame(t, client)
+	assert.Equal(t, "order", frame["event"])
+	assert.Equal(t, "created", frame["type"])
+}
+
+func TestWebSocketBroadcastsStayInTheirTenant(t *testing.T) {
+	fake := startFakeRedis(t)
+	redisService := redis.NewRedisService()
+	defer redisService.Close()
 
-	// ✅ Read response (if any)
-	_, response, err := client.ReadMessage()
-	if err == nil {
-		assert.NotEmpty(t, response)
+	url := serveGateway(t, wsm.NewWebSocketManager(infrastructure.NewMemoryProducer(), redisService, wsm.DefaultConfig()))
+	clients := map[string]*websocket.Conn{
+		"tenant-a": dialGateway(t, url, auth.Claims{Subject: "user-a", Tenant: "tenant-a"}),
+		"tenant-b": dialGateway(t, url, auth.Claims{Subject: "user-b", Tenant: "tenant-b"}),
+	}
+	for _, client := range clients {
+		require.NoError(t, client.WriteMessage(websocket.TextMessage, []byte(`{"type": "subscribe", "channel": "user.created"}`)))
+		assert.Equal(t, "subscribed", readFrame(t, client)["type"])
+	}
+
+	// ✅ Each client's first frame is its own tenant's event, so neither saw the other's
+	fake.Publish("user.created", `{"tenant": "tenant-a", "envelope": {"event": "user", "type": "created", "payload": {"id": 1}}}`)
+	fake.Publish("user.created", `{"envelope": {"event": "user", "type": "created", "payload": {"id": 2}}}`)
+	fake.Publish("user.created", `{"tenant": "tenant-b", "envelope": {"event": "user", "type": "created", "payload": {"id": 3}}}`)
+
+	frame := readFrame(t, clients["tenant-a"])
+	assert.Equal(t, float64(1), frame["payload"].(map[string]interface{})["id"])
+	frame = readFrame(t, clients["tenant-b"])
+	assert.Equal(t, float64(3), frame["payload"].(map[string]interface{})["id"])
+	assert.NotContains(t, frame, "tenant", "routing fields are not forwarded")
+}
+
+func TestWebSocketRepliesReachOnlyTheIssuingConnection(t *testing.T) {
+	fake := startFakeRedis(t)
+	redisService := redis.NewRedisService()
+	defer redisService.Close()
+
+	producer := infrastructure.NewMemoryProducer()
+	url := serveGateway(t, wsm.NewWebSocketManager(producer, redisService, wsm.DefaultConfig()))
+	fake.WaitSubscribed(t, wsm.RedisChannel, 1)
+
+	// ✅ Each client issues a command; the produced command names its connection
+	clients := []*websocket.Conn{
+		dialGateway(t, url, auth.Claims{Subject: "user-a", Tenant: "tenant-1"}),
+		dialGateway(t, url, auth.Claims{Subject: "user-b", Tenant: "tenant-1"}),
+	}
+	connectionIDs := make([]string, len(clients))
+	for i, client := range clients {
+		message := fmt.Sprintf(`{"type": "fetch", "event": "user", "request_id": "r-%d", "data": {"user_id": 1}}`, i)
+		require.NoError(t, client.WriteMessage(websocket.TextMessage, []byte(message)))
+		require.Equal(t, "ack", readFrame(t, client)["type"])
+
+		produced := producer.Messages()
+		require.Len(t, produced, i+1)
+		connectionIDs[i] = produced[i].Headers.Get(infrastructure.HeaderConnectionID)
+		require.NotEmpty(t, connectionIDs[i])
+	}
+	require.NotEqual(t, connectionIDs[0], connectionIDs[1])
+
+	// ✅ Replies in the opposite order: each client's first frame must be its own
+	for i := len(clients) - 1; i >= 0; i-- {
+		reply := fmt.Sprintf(`{"connection_id": %q, "envelope": {"event": "user", "type": "fetch", "correlation_id": "r-%d"}}`, connectionIDs[i], i)
+		fake.Publish(wsm.RedisChannel, reply)
+	}
+	fake.Publish(wsm.RedisChannel, `{"connection_id": "on-another-gateway", "envelope": {"event": "user", "type": "fetch"}}`)
+
+	for i, client := range clients {
+		frame := readFrame(t, client)
+		assert.Equal(t, fmt.Sprintf("r-%d", i), frame["correlation_id"])
+		assert.NotContains(t, frame, "connection_id", "routing fields are not forwarded")
+	}
+
+	// ✅ And the next one too, so nothing else was queued for them
+	for i := range clients {
+		reply := fmt.Sprintf(`{"connection_id": %q, "envelope": {"event": "user", "type": "fetch", "correlation_id": "done-%d"}}`, connectionIDs[i], i)
+		fake.Publish(wsm.RedisChannel, reply)
+	}
+	for i, client := range clients {
+		assert.Equal(t, fmt.Sprintf("done-%d", i), readFrame(t, client)["correlation_id"])
 	}
 }
@@ -0,0 +1,133 @@
+package websocket_test
+
+import (
+	"encoding/binary"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"GoSyntaxDoc/domain/entities"
+	wsm "GoSyntaxDoc/presentation/websocket"
+)
+
+// recordingConn - A wsm.FrameWriter that keeps what the client's writer sent
+type recordingConn struct {
+	mu          sync.Mutex
+	frames      []string
+	closeCode   int
+	closeReason string
+
+	closeOnce sync.Once
+	closed    chan struct{}
+}
+
+func newRecordingConn() *recordingConn {
+	return &recordingConn{closed: make(chan struct{})}
+}
+
+func (c *recordingConn) WriteMessage(messageType int, data []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.frames = append(c.frames, string(data))
+	return nil
+}
+
+func (c *recordingConn) WriteControl(messageType int, data []byte, deadline time.Time) error {
+	if messageType == websocket.CloseMessage && len(data) >= 2 {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		c.closeCode = int(binary.BigEndian.Uint16(data))
+		c.closeReason = string(data[2:])
+	}
+	return nil
+}
+
+func (c *recordingConn) SetWriteDeadline(time.Time) error { return nil }
+
+func (c *recordingConn) Close() error {
+	c.closeOnce.Do(func() { close(c.closed) })
+	return nil
+}
+
+func (c *recordingConn) Frames() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]string(nil), c.frames...)
+}
+
+// waitClosed blocks until the client's writer has closed the connection
+func (c *recordingConn) waitClosed(t *testing.T) {
+	t.Helper()
+	select {
+	case <-c.closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("connection was not closed")
+	}
+}
+
+// newQueueClient: A client with a two-frame queue whose writer has not started
+func newQueueClient(policy wsm.OverflowPolicy) (*wsm.Client, *recordingConn) {
+	cfg := wsm.DefaultConfig()
+	cfg.SendBufferSize = 2
+	cfg.OverflowPolicy = policy
+	conn := newRecordingConn()
+	return wsm.NewClient(conn, &entities.Identity{Subject: "user-1"}, cfg), conn
+}
+
+func enqueue(client *wsm.Client, frames ...string) {
+	for _, frame := range frames {
+		client.Enqueue([]byte(frame))
+	}
+}
+
+func TestOverflowDropOldestKeepsTheNewestFrames(t *testing.T) {
+	client, conn := newQueueClient(wsm.DropOldest)
+	enqueue(client, "f1", "f2", "f3", "f4", "f5")
+
+	assert.Equal(t, 2, client.QueueDepth())
+	assert.Equal(t, int64(2), client.MaxQueueDepth())
+	assert.Equal(t, int64(3), client.Dropped())
+
+	client.Start()
+	require.Eventually(t, func() bool { return len(conn.Frames()) == 2 }, 5*time.Second, time.Millisecond)
+	assert.Equal(t, []string{"f4", "f5"}, conn.Frames())
+	assert.Equal(t, 0, client.QueueDepth())
+	assert.Equal(t, int64(2), client.MaxQueueDepth(), "the high-water mark stays")
+}
+
+func TestOverflowDropNewestKeepsTheOldestFrames(t *testing.T) {
+	client, conn := newQueueClient(wsm.DropNewest)
+	enqueue(client, "f1", "f2", "f3", "f4", "f5")
+
+	assert.Equal(t, 2, client.QueueDepth())
+	assert.Equal(t, int64(3), client.Dropped())
+
+	client.Start()
+	require.Eventually(t, func() bool { return len(conn.Frames()) == 2 }, 5*time.Second, time.Millisecond)
+	assert.Equal(t, []string{"f1", "f2"}, conn.Frames())
+}
+
+func TestOverflowDisconnectClosesTheClient(t *testing.T) {
+	client, conn := newQueueClient(wsm.Disconnect)
+	enqueue(client, "f1", "f2")
+	assert.Zero(t, client.Dropped(), "a full queue is not an overflow yet")
+
+	enqueue(client, "f3")
+	assert.Equal(t, int64(1), client.Dropped())
+
+	// ✅ Once stopped, frames are ignored rather than counted
+	enqueue(client, "f4")
+	assert.Equal(t, int64(1), client.Dropped())
+	assert.Equal(t, 2, client.QueueDepth())
+
+	client.Start()
+	conn.waitClosed(t)
+	assert.Equal(t, wsm.DefaultConfig().OverflowCloseCode, conn.closeCode)
+	assert.Equal(t, wsm.ReasonQueueOverflow, conn.closeReason)
+	assert.NotContains(t, conn.Frames(), "f3")
+	assert.NotContains(t, conn.Frames(), "f4")
+}
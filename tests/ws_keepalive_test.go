@@ -0,0 +1,125 @@
+package websocket_test
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"GoSyntaxDoc/infrastructure"
+	"GoSyntaxDoc/infrastructure/auth"
+	"GoSyntaxDoc/infrastructure/redis"
+	wsm "GoSyntaxDoc/presentation/websocket"
+)
+
+// keepaliveConfig: Pings every 20ms and a 100ms read deadline
+func keepaliveConfig(idle time.Duration) wsm.Config {
+	cfg := wsm.DefaultConfig()
+	cfg.PingInterval = 20 * time.Millisecond
+	cfg.PongWait = 100 * time.Millisecond
+	cfg.IdleTimeout = idle
+	return cfg
+}
+
+// keepalivePeer - A client that reads continuously, so ping handlers run
+type keepalivePeer struct {
+	conn    *websocket.Conn
+	frames  chan []byte // Closed when the connection ends, after err is set
+	pings   atomic.Int64
+	readErr error
+}
+
+// dialKeepalive connects to a gateway with cfg; answerPings decides whether the peer pongs
+func dialKeepalive(t *testing.T, cfg wsm.Config, answerPings bool) *keepalivePeer {
+	t.Helper()
+	t.Setenv("REDIS_ADDR", "127.0.0.1:1")
+	redisService := redis.NewRedisService()
+	t.Cleanup(redisService.Close)
+
+	url := serveGateway(t, wsm.NewWebSocketManager(infrastructure.NewMemoryProducer(), redisService, cfg))
+	peer := &keepalivePeer{
+		conn:   dialGateway(t, url, auth.Claims{Subject: "user-1", Tenant: "tenant-1"}),
+		frames: make(chan []byte, 16),
+	}
+	peer.conn.SetPingHandler(func(data string) error {
+		peer.pings.Add(1)
+		if !answerPings {
+			return nil
+		}
+		return peer.conn.WriteControl(websocket.PongMessage, []byte(data), time.Now().Add(time.Second))
+	})
+
+	go func() {
+		defer close(peer.frames)
+		for {
+			_, frame, err := peer.conn.ReadMessage()
+			if err != nil {
+				peer.readErr = err
+				return
+			}
+			peer.frames <- frame
+		}
+	}()
+	return peer
+}
+
+// waitForClose drains frames until the connection ends and returns its close error
+func (p *keepalivePeer) waitForClose(t *testing.T) *websocket.CloseError {
+	t.Helper()
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case _, ok := <-p.frames:
+			if ok {
+				continue
+			}
+			var closeErr *websocket.CloseError
+			require.True(t, errors.As(p.readErr, &closeErr), "closed without a close frame: %v", p.readErr)
+			return closeErr
+		case <-timeout:
+			t.Fatal("connection was not closed")
+			return nil
+		}
+	}
+}
+
+func TestPongsKeepAQuietConnectionOpen(t *testing.T) {
+	peer := dialKeepalive(t, keepaliveConfig(time.Minute), true)
+
+	// ✅ Several read deadlines pass with no application frame, only pongs
+	time.Sleep(400 * time.Millisecond)
+	assert.GreaterOrEqual(t, peer.pings.Load(), int64(5))
+
+	require.NoError(t, peer.conn.WriteMessage(websocket.TextMessage, []byte(`{"type": "read", "event": "user", "request_id": "r-1"}`)))
+	select {
+	case frame, ok := <-peer.frames:
+		require.True(t, ok, "connection closed")
+		assert.Contains(t, string(frame), `"ack"`)
+	case <-time.After(5 * time.Second):
+		t.Fatal("no ack")
+	}
+}
+
+func TestMissedPongsCloseWithGoingAway(t *testing.T) {
+	peer := dialKeepalive(t, keepaliveConfig(time.Minute), false)
+
+	closeErr := peer.waitForClose(t)
+	assert.Equal(t, websocket.CloseGoingAway, closeErr.Code)
+	assert.Equal(t, wsm.ReasonPongTimeout, closeErr.Text)
+	assert.Positive(t, peer.pings.Load())
+}
+
+func TestIdleConnectionsAreClosed(t *testing.T) {
+	start := time.Now()
+	peer := dialKeepalive(t, keepaliveConfig(150*time.Millisecond), true)
+
+	// ✅ Answering pings is not activity; only application frames are
+	closeErr := peer.waitForClose(t)
+	assert.Equal(t, websocket.CloseNormalClosure, closeErr.Code)
+	assert.Equal(t, wsm.ReasonIdleTimeout, closeErr.Text)
+	assert.GreaterOrEqual(t, time.Since(start), 150*time.Millisecond)
+}